@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+	"github.com/cryptoSelect/fundsTask/valuescan"
+
+	"github.com/cryptoSelect/public/database"
+)
+
+const (
+	AuthOutcomeSuccess = "success"
+	AuthOutcomeFailure = "failure"
+)
+
+// AuthEvent 认证审计记录（登录、刷新、发送验证码）
+type AuthEvent struct {
+	ID          uint      `gorm:"primaryKey;comment:主键ID" json:"id"`
+	CreatedAt   time.Time `gorm:"index;comment:事件时间" json:"createdAt"`
+	Account     string    `gorm:"index;comment:登录账号（脱敏），用于展示" json:"account"`
+	AccountHash string    `gorm:"index;size:64;comment:登录账号的 SHA-256（十六进制），用于按账号查询" json:"-"`
+	Action      string    `gorm:"comment:认证动作(login/refresh/sendCode)" json:"action"`
+	Outcome     string    `gorm:"index;comment:结果(success/failure)" json:"outcome"`
+	Code        int       `gorm:"comment:ValueScan 业务码" json:"code"`
+	ReqID       string    `gorm:"comment:ValueScan reqId" json:"reqId"`
+	UserRole    string    `gorm:"comment:ValueScan userRole" json:"userRole"`
+	Reason      string    `gorm:"type:text;comment:失败原因" json:"reason"`
+}
+
+func (AuthEvent) TableName() string {
+	return "auth_event"
+}
+
+// AuthEventFilter 审计记录查询条件
+type AuthEventFilter struct {
+	Account string    // 原始账号，按其哈希精确匹配
+	Outcome string    // success / failure，留空不过滤
+	Since   time.Time // 零值不过滤
+	Limit   int
+}
+
+// recordAuthEvent 记录一次认证事件，数据库未初始化或写入失败时只记录日志
+// 调用方取消时仍然写入，保证审计记录完整
+func recordAuthEvent(ctx context.Context, account, action string, meta *valuescan.Meta, err error) {
+	if database.DB == nil {
+		return
+	}
+
+	event := AuthEvent{
+		Account:     MaskAccount(account),
+		AccountHash: hashAccount(account),
+		Action:      action,
+		Outcome:     AuthOutcomeSuccess,
+	}
+	if meta != nil {
+		event.Code = meta.Code
+		event.ReqID = meta.ReqID
+		event.UserRole = meta.UserRole
+	}
+	if err != nil {
+		event.Outcome = AuthOutcomeFailure
+		event.Reason = err.Error()
+	}
+
+	if result := database.DB.WithContext(context.WithoutCancel(ctx)).Create(&event); result.Error != nil {
+		logger.Log.Warn("Failed to record auth event", map[string]interface{}{
+			"action": action,
+			"error":  result.Error,
+		})
+	}
+}
+
+// QueryAuthEvents 按条件查询认证审计记录，按时间倒序
+func QueryAuthEvents(ctx context.Context, filter AuthEventFilter) ([]AuthEvent, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := database.DB.WithContext(ctx).Model(&AuthEvent{}).Order("created_at DESC")
+	if filter.Account != "" {
+		query = query.Where("account_hash = ?", hashAccount(filter.Account))
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var events []AuthEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to query auth events: %w", err)
+	}
+
+	return events, nil
+}
+
+// hashAccount 返回账号的 SHA-256 十六进制摘要；脱敏后的账号可能相同，按账号查询时使用摘要区分
+func hashAccount(account string) string {
+	sum := sha256.Sum256([]byte(account))
+	return hex.EncodeToString(sum[:])
+}
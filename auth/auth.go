@@ -1,72 +1,76 @@
 package auth
 
 import (
-	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cryptoSelect/fundsTask/config"
 	"github.com/cryptoSelect/fundsTask/utils/logger"
+	"github.com/cryptoSelect/fundsTask/valuescan"
 )
 
 const (
-	LoginURL = "https://api.valuescan.io/api/authority/login"
+	// DefaultRefreshMargin 未配置时，令牌到期前提前续期的时长
+	DefaultRefreshMargin = config.DefaultRefreshMarginSeconds * time.Second
 )
 
-// LoginRequest 登录请求结构
-type LoginRequest struct {
-	PhoneOrEmail  string `json:"phoneOrEmail"`
-	Code          string `json:"code"`
-	EndpointEnum  int    `json:"endpointEnum"`
-	LoginTypeEnum int    `json:"loginTypeEnum"`
-}
-
-// LoginResponse 登录响应结构
-type LoginResponse struct {
-	Code     int       `json:"code"`
-	Data     LoginData `json:"data"`
-	Msg      string    `json:"msg"`
-	ReqID    string    `json:"reqId"`
-	UserRole string    `json:"userRole"`
-}
-
-// LoginData 登录数据
-type LoginData struct {
-	AccountToken string `json:"account_token"`
-	RefreshToken string `json:"refresh_token"`
-}
-
 // TokenPair 令牌对，用于存储获取到的令牌
 type TokenPair struct {
 	AccountToken     string `json:"account_token"`
 	RefreshToken     string `json:"refresh_token"`
+	IssuedAt         int64  `json:"issued_at,omitempty"`          // 签发时间戳（可选）
 	ExpiresAt        int64  `json:"expires_at,omitempty"`         // 过期时间戳（可选）
 	RefreshExpiresAt int64  `json:"refresh_expires_at,omitempty"` // 刷新令牌过期时间戳（可选）
 }
 
+// jwtClaims JWT payload 中关心的字段
+type jwtClaims struct {
+	Exp float64 `json:"exp"`
+	Iat float64 `json:"iat"`
+}
+
 // AuthService 认证服务
 type AuthService struct {
-	client *http.Client
+	client  *valuescan.Client
+	store   TokenStore
+	account config.LoginConfig
 }
 
-// NewTokenPair 创建新的令牌对
+// NewTokenPair 创建新的令牌对，并从 JWT 中解析签发与过期时间
 func NewTokenPair(accountToken, refreshToken string) *TokenPair {
-	return &TokenPair{
+	tp := &TokenPair{
 		AccountToken: accountToken,
 		RefreshToken: refreshToken,
 	}
+
+	if claims, err := parseJWTClaims(accountToken); err == nil {
+		tp.IssuedAt = int64(claims.Iat)
+		tp.ExpiresAt = int64(claims.Exp)
+	} else if accountToken != "" {
+		logger.Log.Debug("Failed to parse account token claims", map[string]interface{}{"error": err})
+	}
+
+	if claims, err := parseJWTClaims(refreshToken); err == nil {
+		tp.RefreshExpiresAt = int64(claims.Exp)
+	} else if refreshToken != "" {
+		logger.Log.Debug("Failed to parse refresh token claims", map[string]interface{}{"error": err})
+	}
+
+	return tp
 }
 
-// IsValid 检查令牌是否有效（基于过期时间）
+// IsValid 检查令牌是否有效（基于过期时间，预留续期余量）
 func (tp *TokenPair) IsValid() bool {
 	if tp.ExpiresAt == 0 {
 		return true // 如果没有设置过期时间，默认有效
 	}
 
-	now := time.Now().Unix()
+	now := time.Now().Add(refreshMargin()).Unix()
 	return now < tp.ExpiresAt
 }
 
@@ -76,104 +80,131 @@ func (tp *TokenPair) IsRefreshValid() bool {
 		return true // 如果没有设置过期时间，默认有效
 	}
 
-	now := time.Now().Unix()
+	now := time.Now().Add(refreshMargin()).Unix()
 	return now < tp.RefreshExpiresAt
 }
 
-// NewAuthService 创建认证服务实例
-func NewAuthService() *AuthService {
+// refreshMargin 获取提前续期的余量
+func refreshMargin() time.Duration {
+	cfg := config.Current()
+	if cfg == nil || cfg.Auth.RefreshMarginSeconds <= 0 {
+		return DefaultRefreshMargin
+	}
+	return time.Duration(cfg.Auth.RefreshMarginSeconds) * time.Second
+}
+
+// NewAuthService 创建认证服务实例（使用配置中的第一个账号）
+func NewAuthService(client *valuescan.Client) *AuthService {
+	return NewAccountAuthService(client, config.Current().Login.Primary())
+}
+
+// NewAccountAuthService 创建指定账号的认证服务实例
+func NewAccountAuthService(client *valuescan.Client, account config.LoginConfig) *AuthService {
 	return &AuthService{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:  client,
+		store:   NewTokenStore(),
+		account: account,
 	}
 }
 
-// Login 执行登录
-func (s *AuthService) Login() (*LoginResponse, error) {
+// Account 返回认证服务使用的账号
+func (s *AuthService) Account() string {
+	return s.account.PhoneOrEmail
+}
+
+// Login 使用配置中的登录码执行登录
+func (s *AuthService) Login(ctx context.Context) (*valuescan.Response[valuescan.LoginData], error) {
+	return s.LoginWithCode(ctx, s.account.Code)
+}
+
+// LoginWithCode 使用指定的验证码执行登录
+func (s *AuthService) LoginWithCode(ctx context.Context, code string) (*valuescan.Response[valuescan.LoginData], error) {
 	logger.Log.Debug("Starting login process", map[string]interface{}{
-		"email": config.Cfg.Login.PhoneOrEmail,
+		"account": MaskAccount(s.account.PhoneOrEmail),
 	})
 
-	// 构建请求体
-	loginReq := LoginRequest{
-		PhoneOrEmail:  config.Cfg.Login.PhoneOrEmail,
-		Code:          config.Cfg.Login.Code,
+	resp, err := s.client.Login(ctx, valuescan.LoginRequest{
+		PhoneOrEmail:  s.account.PhoneOrEmail,
+		Code:          code,
 		EndpointEnum:  1,
 		LoginTypeEnum: 2,
+	})
+	if err := s.finishAuth(ctx, "login", metaOf(resp, err), err); err != nil {
+		return nil, err
 	}
+	return resp, nil
+}
 
-	// 序列化请求体
-	reqBody, err := json.Marshal(loginReq)
-	if err != nil {
-		logger.Log.Error("Failed to marshal login request", map[string]interface{}{"error": err})
-		return nil, fmt.Errorf("failed to marshal login request: %w", err)
-	}
+// Refresh 使用刷新令牌换取新的访问令牌
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*valuescan.Response[valuescan.LoginData], error) {
+	logger.Log.Debug("Starting token refresh", nil)
 
-	// 创建 HTTP 请求
-	req, err := http.NewRequest("POST", LoginURL, bytes.NewBuffer(reqBody))
-	if err != nil {
-		logger.Log.Error("Failed to create login request", map[string]interface{}{"error": err})
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	resp, err := s.client.Refresh(ctx, valuescan.RefreshRequest{
+		RefreshToken: refreshToken,
+		PhoneOrEmail: s.account.PhoneOrEmail,
+	})
+	if err := s.finishAuth(ctx, "refresh", metaOf(resp, err), err); err != nil {
+		return nil, err
 	}
+	return resp, nil
+}
 
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
-
-	// 发送请求
-	logger.Log.Debug("Sending login request", map[string]interface{}{"url": LoginURL})
-	resp, err := s.client.Do(req)
-	if err != nil {
-		logger.Log.Error("Failed to send login request", map[string]interface{}{"error": err})
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+// SendCode 请求 ValueScan 向账号发送登录验证码
+func (s *AuthService) SendCode(ctx context.Context) error {
+	logger.Log.Debug("Requesting login code", map[string]interface{}{
+		"account": MaskAccount(s.account.PhoneOrEmail),
+	})
 
-	// 读取响应体
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logger.Log.Error("Failed to read login response", map[string]interface{}{"error": err})
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
+	resp, err := s.client.SendCode(ctx, valuescan.SendCodeRequest{
+		PhoneOrEmail:  s.account.PhoneOrEmail,
+		EndpointEnum:  1,
+		LoginTypeEnum: 2,
+	})
+	return s.finishAuth(ctx, "sendCode", metaOf(resp, err), err)
+}
 
-	// 检查 HTTP 状态码
-	if resp.StatusCode != http.StatusOK {
-		logger.Log.Error("Login HTTP error", map[string]interface{}{
-			"status_code": resp.StatusCode,
-			"body":        string(body),
-		})
-		return nil, fmt.Errorf("HTTP error: %d, body: %s", resp.StatusCode, string(body))
-	}
+// finishAuth 记录认证请求的结果（日志与审计表），失败时返回带动作名的错误
+func (s *AuthService) finishAuth(ctx context.Context, action string, meta *valuescan.Meta, err error) error {
+	recordAuthEvent(ctx, s.account.PhoneOrEmail, action, meta, err)
 
-	// 解析响应
-	var loginResp LoginResponse
-	err = json.Unmarshal(body, &loginResp)
 	if err != nil {
-		logger.Log.Error("Failed to unmarshal login response", map[string]interface{}{"error": err})
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	// 检查业务状态码
-	if loginResp.Code != 200 {
-		logger.Log.Error("Login failed", map[string]interface{}{
-			"code": loginResp.Code,
-			"msg":  loginResp.Msg,
-		})
-		return nil, fmt.Errorf("login failed: code=%d, msg=%s", loginResp.Code, loginResp.Msg)
+		fields := map[string]interface{}{
+			"action":  action,
+			"account": MaskAccount(s.account.PhoneOrEmail),
+			"error":   err.Error(),
+		}
+		if meta != nil {
+			fields["code"] = meta.Code
+			fields["req_id"] = meta.ReqID
+		}
+		logger.Log.Error("Auth request failed", fields)
+		return fmt.Errorf("%s failed: %w", action, err)
 	}
 
-	logger.Log.Info("Login successful", map[string]interface{}{
-		"user_role": loginResp.UserRole,
-		"req_id":    loginResp.ReqID,
+	logger.Log.Info("Auth request successful", map[string]interface{}{
+		"action":    action,
+		"account":   MaskAccount(s.account.PhoneOrEmail),
+		"user_role": meta.UserRole,
+		"req_id":    meta.ReqID,
 	})
+	return nil
+}
 
-	return &loginResp, nil
+// metaOf 返回响应的公共字段；HTTP 层失败没有响应时取 APIError 中的业务码与 reqId，都没有时返回 nil
+func metaOf[T any](resp *valuescan.Response[T], err error) *valuescan.Meta {
+	if resp != nil {
+		return &resp.Meta
+	}
+	var apiErr *valuescan.APIError
+	if errors.As(err, &apiErr) {
+		return &valuescan.Meta{Code: apiErr.Code, Msg: apiErr.Msg, ReqID: apiErr.ReqID}
+	}
+	return nil
 }
 
 // GetTokens 获取令牌（便捷方法）
-func (s *AuthService) GetTokens() (*TokenPair, error) {
-	resp, err := s.Login()
+func (s *AuthService) GetTokens(ctx context.Context) (*TokenPair, error) {
+	resp, err := s.Login(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -182,44 +213,105 @@ func (s *AuthService) GetTokens() (*TokenPair, error) {
 }
 
 // GetTokensWithExpiry 获取令牌并设置过期时间（基于JWT解析）
-func (s *AuthService) GetTokensWithExpiry() (*TokenPair, error) {
-	resp, err := s.Login()
+//
+// Deprecated: GetTokens 已经会解析过期时间，保留此方法仅为兼容
+func (s *AuthService) GetTokensWithExpiry(ctx context.Context) (*TokenPair, error) {
+	return s.GetTokens(ctx)
+}
+
+// LoadTokens 加载缓存的令牌对，校验后按需续期并写回；没有缓存时重新登录
+func (s *AuthService) LoadTokens(ctx context.Context) (*TokenPair, error) {
+	cached, err := s.loadCachedTokens(ctx)
 	if err != nil {
-		return nil, err
+		logger.Log.Warn("Failed to load cached tokens, logging in", map[string]interface{}{"error": err})
+	}
+
+	if cached == nil {
+		tokenPair, err := s.GetTokens(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.saveTokens(ctx, tokenPair)
+		return tokenPair, nil
 	}
 
-	tokenPair := NewTokenPair(resp.Data.AccountToken, resp.Data.RefreshToken)
+	logger.Log.Info("Loaded cached tokens", map[string]interface{}{
+		"expires_at":         cached.ExpiresAt,
+		"refresh_expires_at": cached.RefreshExpiresAt,
+	})
+
+	return s.ValidateAndRenew(ctx, cached)
+}
 
-	// 尝试从JWT令牌中解析过期时间
-	if exp := extractExpiryFromJWT(resp.Data.AccountToken); exp > 0 {
-		tokenPair.ExpiresAt = exp
+// loadCachedTokens 从令牌存储读取当前账号的令牌对
+func (s *AuthService) loadCachedTokens(ctx context.Context) (*TokenPair, error) {
+	if s.store == nil {
+		return nil, nil
 	}
 
-	if refreshExp := extractExpiryFromJWT(resp.Data.RefreshToken); refreshExp > 0 {
-		tokenPair.RefreshExpiresAt = refreshExp
+	tokenPair, err := s.store.Load(ctx, s.account.PhoneOrEmail)
+	if err != nil || tokenPair == nil || tokenPair.AccountToken == "" {
+		return nil, err
 	}
 
 	return tokenPair, nil
 }
 
-// ValidateAndRefreshToken 验证并刷新 Token
-func (s *AuthService) ValidateAndRefreshToken(accessToken string) (string, error) {
-	// 检查 Token 是否有效
-	tokenPair := &TokenPair{
-		AccountToken: accessToken,
+// saveTokens 将令牌对写回令牌存储，失败只记录日志
+// 新令牌已经生效，即使调用方随后取消也要写入，否则旧的刷新令牌可能已经作废
+func (s *AuthService) saveTokens(ctx context.Context, tp *TokenPair) {
+	if s.store == nil {
+		return
 	}
 
-	if tokenPair.IsValid() {
-		logger.Log.Debug("Token is still valid", nil)
-		return accessToken, nil
+	if err := s.store.Save(context.WithoutCancel(ctx), s.account.PhoneOrEmail, tp); err != nil {
+		logger.Log.Error("Failed to persist tokens", map[string]interface{}{"error": err})
+	}
+}
+
+// RefreshTokens 使用令牌对中的刷新令牌换取新的令牌对
+func (s *AuthService) RefreshTokens(ctx context.Context, tp *TokenPair) (*TokenPair, error) {
+	resp, err := s.Refresh(ctx, tp.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 接口未下发新的刷新令牌时沿用旧的
+	refreshToken := resp.Data.RefreshToken
+	if refreshToken == "" {
+		refreshToken = tp.RefreshToken
 	}
 
-	logger.Log.Info("Token expired, attempting re-login", nil)
+	return NewTokenPair(resp.Data.AccountToken, refreshToken), nil
+}
+
+// RenewTokens 续期令牌：优先使用刷新令牌，未配置刷新接口、刷新令牌过期或被拒绝时重新登录
+func (s *AuthService) RenewTokens(ctx context.Context, tp *TokenPair) (*TokenPair, error) {
+	switch {
+	case s.client.Endpoints().Refresh == "":
+		logger.Log.Info("Refresh endpoint not configured, attempting re-login", nil)
+	case tp != nil && tp.RefreshToken != "" && tp.IsRefreshValid():
+		newTokenPair, err := s.RefreshTokens(ctx, tp)
+		if err == nil {
+			logger.Log.Info("Successfully refreshed token", map[string]interface{}{
+				"expires_at": newTokenPair.ExpiresAt,
+			})
+			s.saveTokens(ctx, newTokenPair)
+			return newTokenPair, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		logger.Log.Warn("Token refresh rejected, falling back to re-login", map[string]interface{}{"error": err})
+	default:
+		logger.Log.Info("Refresh token unavailable or expired, attempting re-login", nil)
+	}
 
 	// 重新登录获取新 Token
-	newTokenPair, err := s.GetTokens()
+	newTokenPair, err := s.GetTokens(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to re-login: %w", err)
+		return nil, fmt.Errorf("failed to re-login: %w", err)
 	}
 
 	logger.Log.Info("Successfully re-logged in", map[string]interface{}{
@@ -227,13 +319,72 @@ func (s *AuthService) ValidateAndRefreshToken(accessToken string) (string, error
 		"account_token_len": len(newTokenPair.AccountToken),
 	})
 
-	return newTokenPair.AccountToken, nil
+	s.saveTokens(ctx, newTokenPair)
+	return newTokenPair, nil
 }
 
-// extractExpiryFromJWT 从JWT令牌中提取过期时间（简单实现）
-func extractExpiryFromJWT(token string) int64 {
-	// 这里应该解析JWT的payload部分获取exp字段
-	// 为了简化，这里返回0，表示不设置过期时间
-	// 在实际项目中，可以使用jwt库来解析
-	return 0
+// ValidateAndRenew 验证令牌对，即将过期时续期
+func (s *AuthService) ValidateAndRenew(ctx context.Context, tp *TokenPair) (*TokenPair, error) {
+	if tp.IsValid() {
+		logger.Log.Debug("Token is still valid", nil)
+		return tp, nil
+	}
+
+	logger.Log.Info("Token expired, attempting renewal", map[string]interface{}{
+		"expires_at": tp.ExpiresAt,
+	})
+
+	return s.RenewTokens(ctx, tp)
+}
+
+// ValidateAndRefreshToken 验证并刷新 Token（只有访问令牌时只能重新登录）
+func (s *AuthService) ValidateAndRefreshToken(ctx context.Context, accessToken string) (string, error) {
+	tokenPair, err := s.ValidateAndRenew(ctx, NewTokenPair(accessToken, ""))
+	if err != nil {
+		return "", err
+	}
+
+	return tokenPair.AccountToken, nil
+}
+
+// MaskAccount 隐藏账号中间部分，用于日志与审计
+func MaskAccount(account string) string {
+	runes := []rune(account)
+	if at := strings.IndexRune(account, '@'); at > 0 {
+		name := []rune(account[:at])
+		if len(name) <= 2 {
+			return string(name[:1]) + "***" + account[at:]
+		}
+		return string(name[:2]) + "***" + account[at:]
+	}
+
+	if len(runes) <= 4 {
+		return "***"
+	}
+	return string(runes[:3]) + "***" + string(runes[len(runes)-2:])
+}
+
+// parseJWTClaims 解析 JWT payload 中的 exp 与 iat（不校验签名）
+func parseJWTClaims(token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed JWT: expected 3 parts, got %d", len(parts))
+	}
+
+	// JWT 使用无填充的 base64url，这里兼容带填充的情况
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWT payload: %w", err)
+	}
+
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JWT claims: %w", err)
+	}
+
+	if claims.Exp <= 0 {
+		return nil, fmt.Errorf("JWT has no exp claim")
+	}
+
+	return &claims, nil
 }
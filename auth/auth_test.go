@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+// testJWT 生成带指定 payload 的未签名 JWT
+func testJWT(payload string) string {
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+}
+
+func TestParseJWTClaims(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		wantExp float64
+		wantIat float64
+		wantErr bool
+	}{
+		{name: "valid", token: testJWT(`{"exp":2000000000,"iat":1700000000}`), wantExp: 2000000000, wantIat: 1700000000},
+		{name: "padded payload", token: "h." + base64.URLEncoding.EncodeToString([]byte(`{"exp":2000000000}`)) + ".s", wantExp: 2000000000},
+		{name: "expired", token: testJWT(`{"exp":1000000000}`), wantExp: 1000000000},
+		{name: "missing exp", token: testJWT(`{"iat":1700000000}`), wantErr: true},
+		{name: "two parts", token: "header.payload", wantErr: true},
+		{name: "invalid base64", token: "h.!!!.s", wantErr: true},
+		{name: "invalid json", token: testJWT(`not json`), wantErr: true},
+		{name: "empty", token: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := parseJWTClaims(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseJWTClaims = %+v, want error", claims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseJWTClaims: %v", err)
+			}
+			if claims.Exp != tt.wantExp || claims.Iat != tt.wantIat {
+				t.Fatalf("claims = %+v, want exp %v iat %v", claims, tt.wantExp, tt.wantIat)
+			}
+		})
+	}
+}
+
+func TestTokenPairValidity(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) int64 { return now.Add(d).Unix() }
+
+	tests := []struct {
+		name      string
+		expiresAt int64
+		want      bool
+	}{
+		{name: "no expiry", expiresAt: 0, want: true},
+		{name: "far from expiry", expiresAt: at(time.Hour), want: true},
+		{name: "within refresh margin", expiresAt: at(DefaultRefreshMargin / 2), want: false},
+		{name: "just outside refresh margin", expiresAt: at(DefaultRefreshMargin + 5*time.Second), want: true},
+		{name: "expired", expiresAt: at(-time.Minute), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := &TokenPair{ExpiresAt: tt.expiresAt, RefreshExpiresAt: tt.expiresAt}
+			if got := tp.IsValid(); got != tt.want {
+				t.Fatalf("IsValid() = %v, want %v", got, tt.want)
+			}
+			if got := tp.IsRefreshValid(); got != tt.want {
+				t.Fatalf("IsRefreshValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTokenPairParsesExpiry(t *testing.T) {
+	tests := []struct {
+		name          string
+		accountToken  string
+		refreshToken  string
+		wantExpires   int64
+		wantIssued    int64
+		wantRefreshAt int64
+	}{
+		{
+			name:          "both jwt",
+			accountToken:  testJWT(`{"exp":2000000000,"iat":1700000000}`),
+			refreshToken:  testJWT(`{"exp":2100000000}`),
+			wantExpires:   2000000000,
+			wantIssued:    1700000000,
+			wantRefreshAt: 2100000000,
+		},
+		{name: "opaque tokens", accountToken: "opaque", refreshToken: "opaque-refresh"},
+		{name: "missing exp", accountToken: testJWT(`{"iat":1700000000}`), refreshToken: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := NewTokenPair(tt.accountToken, tt.refreshToken)
+			if tp.ExpiresAt != tt.wantExpires || tp.IssuedAt != tt.wantIssued || tp.RefreshExpiresAt != tt.wantRefreshAt {
+				t.Fatalf("NewTokenPair = %+v, want exp %d iat %d refresh exp %d", tp, tt.wantExpires, tt.wantIssued, tt.wantRefreshAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cryptoSelect/fundsTask/config"
+	"github.com/cryptoSelect/fundsTask/valuescan"
+)
+
+const (
+	AuthModeLogin  = "login"
+	AuthModeAPIKey = "apiKey"
+	AuthModeNone   = "none"
+)
+
+// Authenticator 为请求提供凭证并管理凭证的生命周期
+type Authenticator interface {
+	// Acquire 获取一份凭证，用于一次逻辑请求（包括续期后的重试）
+	Acquire() (Credential, error)
+}
+
+// Credential 一次逻辑请求使用的凭证
+type Credential interface {
+	// Apply 为请求附加凭证，请求头按接口约定选择；需要续期时使用请求的 context
+	Apply(req *http.Request) error
+	// Renew 凭证被服务端拒绝后续期，失败时返回包装了 ErrRenewFailed 的错误
+	Renew(ctx context.Context) error
+	// Release 归还凭证并反馈请求结果，返回 true 表示可以换一份凭证重试
+	Release(err error) bool
+}
+
+// NewAuthenticator 根据配置创建认证器，登录与续期通过 client 发送
+func NewAuthenticator(ctx context.Context, client *valuescan.Client) (Authenticator, error) {
+	cfg := config.Current()
+	switch cfg.Auth.Mode {
+	case AuthModeLogin, "":
+		pool := NewAccountPool(client, cfg.Login)
+		if err := pool.Init(ctx); err != nil {
+			return nil, err
+		}
+		return NewSessionAuthenticator(pool), nil
+	case AuthModeAPIKey:
+		if cfg.Auth.APIKey == "" {
+			return nil, fmt.Errorf("auth.apiKey is required in %s mode", AuthModeAPIKey)
+		}
+		return NewStaticKeyAuthenticator(cfg.Auth.APIKey), nil
+	case AuthModeNone:
+		return NewNoAuthAuthenticator(), nil
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", cfg.Auth.Mode)
+	}
+}
+
+// setTokenHeader 按接口约定设置凭证请求头：资金流向接口使用 accessToken，其余使用 Authorization: Bearer
+// 按路径后缀匹配，兼容 baseURL 带路径前缀的代理地址
+func setTokenHeader(req *http.Request, token string) {
+	if strings.HasSuffix(req.URL.Path, config.Current().ValueScan.Paths.TradeInflow) {
+		req.Header.Set("accessToken", token)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+// SessionAuthenticator 基于 ValueScan 登录会话的认证器，在账号池中轮换账号
+type SessionAuthenticator struct {
+	pool *AccountPool
+}
+
+// NewSessionAuthenticator 创建登录会话认证器
+func NewSessionAuthenticator(pool *AccountPool) *SessionAuthenticator {
+	return &SessionAuthenticator{pool: pool}
+}
+
+// Acquire 从账号池轮询选取一个账号
+func (a *SessionAuthenticator) Acquire() (Credential, error) {
+	manager, err := a.pool.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	return &sessionCredential{pool: a.pool, manager: manager}, nil
+}
+
+// sessionCredential 账号会话凭证
+type sessionCredential struct {
+	pool    *AccountPool
+	manager *TokenManager
+	token   string
+}
+
+// Apply 附加当前有效的访问令牌（即将过期时自动续期）
+func (c *sessionCredential) Apply(req *http.Request) error {
+	token, err := c.manager.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	c.token = token
+	setTokenHeader(req, token)
+	return nil
+}
+
+// Account 返回凭证所属的账号，用于按账号限速
+func (c *sessionCredential) Account() string {
+	return c.manager.Account()
+}
+
+// CanRotate 账号池中有其它账号时可以换号，被限流时客户端不在当前账号上重试
+func (c *sessionCredential) CanRotate() bool {
+	return c.pool.Size() > 1
+}
+
+// Renew 令牌被拒绝后强制续期
+func (c *sessionCredential) Renew(ctx context.Context) error {
+	token, err := c.manager.Renew(ctx, c.token)
+	if err != nil {
+		return err
+	}
+	c.token = token
+	return nil
+}
+
+// Release 限流或续期失败的账号移出轮换，并允许换账号重试
+func (c *sessionCredential) Release(err error) bool {
+	switch {
+	case errors.Is(err, ErrThrottled):
+		c.pool.MarkThrottled(c.manager, err)
+		return true
+	case errors.Is(err, ErrRenewFailed):
+		c.pool.Disable(c.manager, err)
+		return true
+	default:
+		return false
+	}
+}
+
+// StaticKeyAuthenticator 使用固定 API Key 的认证器
+type StaticKeyAuthenticator struct {
+	key string
+}
+
+// NewStaticKeyAuthenticator 创建固定 API Key 认证器
+func NewStaticKeyAuthenticator(key string) *StaticKeyAuthenticator {
+	return &StaticKeyAuthenticator{key: key}
+}
+
+// Acquire 返回固定 API Key 凭证
+func (a *StaticKeyAuthenticator) Acquire() (Credential, error) {
+	return a, nil
+}
+
+// Apply 附加 API Key
+func (a *StaticKeyAuthenticator) Apply(req *http.Request) error {
+	setTokenHeader(req, a.key)
+	return nil
+}
+
+// Renew 固定 API Key 无法续期
+func (a *StaticKeyAuthenticator) Renew(context.Context) error {
+	return fmt.Errorf("%w: static api key cannot be renewed", ErrRenewFailed)
+}
+
+// Release 固定 API Key 没有可替换的凭证
+func (a *StaticKeyAuthenticator) Release(error) bool {
+	return false
+}
+
+// NoAuthAuthenticator 不附加任何凭证，用于本地假服务
+type NoAuthAuthenticator struct{}
+
+// NewNoAuthAuthenticator 创建无认证认证器
+func NewNoAuthAuthenticator() *NoAuthAuthenticator {
+	return &NoAuthAuthenticator{}
+}
+
+// Acquire 返回空凭证
+func (a *NoAuthAuthenticator) Acquire() (Credential, error) {
+	return a, nil
+}
+
+// Apply 不附加凭证
+func (a *NoAuthAuthenticator) Apply(*http.Request) error {
+	return nil
+}
+
+// Renew 无凭证可续期
+func (a *NoAuthAuthenticator) Renew(context.Context) error {
+	return fmt.Errorf("%w: no-auth mode has nothing to renew", ErrRenewFailed)
+}
+
+// Release 无凭证可替换
+func (a *NoAuthAuthenticator) Release(error) bool {
+	return false
+}
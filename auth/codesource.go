@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+	"github.com/cryptoSelect/fundsTask/valuescan"
+)
+
+// CodeSource 登录验证码来源
+type CodeSource interface {
+	// Prepare 在请求发送验证码之前调用，用于记录基线（如邮箱中已有的邮件）
+	Prepare(ctx context.Context) error
+	// WaitForCode 阻塞等待并返回新的验证码，ctx 取消时返回其错误
+	WaitForCode(ctx context.Context) (string, error)
+	// Close 释放资源
+	Close() error
+}
+
+// StdinCodeSource 从终端读取验证码
+type StdinCodeSource struct {
+	in     *bufio.Reader
+	prompt io.Writer
+}
+
+// NewStdinCodeSource 创建终端验证码来源
+func NewStdinCodeSource(in io.Reader, prompt io.Writer) *StdinCodeSource {
+	return &StdinCodeSource{
+		in:     bufio.NewReader(in),
+		prompt: prompt,
+	}
+}
+
+// Prepare 终端输入无需准备
+func (s *StdinCodeSource) Prepare(context.Context) error {
+	return nil
+}
+
+// WaitForCode 提示并读取一行验证码
+func (s *StdinCodeSource) WaitForCode(ctx context.Context) (string, error) {
+	fmt.Fprint(s.prompt, "Enter the login code sent by ValueScan: ")
+
+	// 终端读取无法中断，放到单独的协程中，ctx 取消时直接返回
+	type result struct {
+		line string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		line, err := s.in.ReadString('\n')
+		done <- result{line, err}
+	}()
+
+	var r result
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if r.err != nil && r.line == "" {
+		return "", fmt.Errorf("failed to read login code: %w", r.err)
+	}
+
+	code := strings.TrimSpace(r.line)
+	if code == "" {
+		return "", fmt.Errorf("empty login code")
+	}
+	return code, nil
+}
+
+// Close 终端输入无需释放
+func (s *StdinCodeSource) Close() error {
+	return nil
+}
+
+// Bootstrap 请求验证码、从 source 获取验证码并完成登录，结果写入令牌存储
+func (s *AuthService) Bootstrap(ctx context.Context, source CodeSource) (*TokenPair, error) {
+	defer source.Close()
+
+	if s.client.Endpoints().SendCode == "" {
+		return nil, fmt.Errorf("valuescan.paths.sendCode is not configured: %w", valuescan.ErrEndpointNotConfigured)
+	}
+
+	if err := source.Prepare(ctx); err != nil {
+		return nil, fmt.Errorf("failed to prepare code source: %w", err)
+	}
+
+	if err := s.SendCode(ctx); err != nil {
+		return nil, fmt.Errorf("failed to request login code: %w", err)
+	}
+
+	logger.Log.Info("Login code requested, waiting for code", map[string]interface{}{
+		"account": MaskAccount(s.account.PhoneOrEmail),
+	})
+
+	code, err := source.WaitForCode(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain login code: %w", err)
+	}
+
+	resp, err := s.LoginWithCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenPair := NewTokenPair(resp.Data.AccountToken, resp.Data.RefreshToken)
+	if s.store == nil {
+		logger.Log.Warn("No token store configured, session will not be persisted", nil)
+	}
+	s.saveTokens(ctx, tokenPair)
+
+	return tokenPair, nil
+}
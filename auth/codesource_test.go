@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cryptoSelect/fundsTask/config"
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+	"github.com/cryptoSelect/fundsTask/valuescan"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("dev")
+	os.Exit(m.Run())
+}
+
+func TestBootstrapLogsInWithCodeFromNewMail(t *testing.T) {
+	const account = "user@example.com"
+
+	mailbox := newFakeIMAPServer(t, true, true)
+	mailbox.deliver("111111")
+
+	endpoints := valuescan.DefaultEndpoints()
+	endpoints.SendCode = "/api/authority/sendCode"
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+endpoints.SendCode, func(w http.ResponseWriter, r *http.Request) {
+		var req valuescan.SendCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PhoneOrEmail != account {
+			t.Errorf("sendCode request = %+v, %v", req, err)
+		}
+		// 验证码邮件在 SELECT 之后才到达
+		mailbox.deliver("222222")
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": valuescan.CodeSuccess, "msg": "success", "data": nil})
+	})
+	mux.HandleFunc("POST "+endpoints.Login, func(w http.ResponseWriter, r *http.Request) {
+		var req valuescan.LoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode login request: %v", err)
+		}
+		if req.Code != "222222" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 4000, "msg": "invalid code", "data": ""})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": valuescan.CodeSuccess,
+			"msg":  "success",
+			"data": valuescan.LoginData{AccountToken: "account-token", RefreshToken: "refresh-token"},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	service := &AuthService{
+		client:  valuescan.NewClient(server.URL, valuescan.WithEndpoints(endpoints)),
+		account: config.LoginConfig{PhoneOrEmail: account},
+	}
+
+	tokens, err := service.Bootstrap(context.Background(), newTestIMAPSource(t, mailbox))
+	if err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if tokens.AccountToken != "account-token" || tokens.RefreshToken != "refresh-token" {
+		t.Fatalf("tokens = %+v", tokens)
+	}
+}
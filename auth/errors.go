@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cryptoSelect/fundsTask/valuescan"
+)
+
+var (
+	// ErrTokenRejected 服务端拒绝了当前令牌（HTTP 401/403 或令牌失效业务码）
+	ErrTokenRejected = errors.New("token rejected by server")
+	// ErrRenewFailed 令牌续期（刷新与重新登录）均失败
+	ErrRenewFailed = errors.New("token renewal failed")
+	// ErrThrottled 账号被服务端限流（HTTP 429 或限流业务码）
+	ErrThrottled = errors.New("account throttled by server")
+	// ErrNoAvailableAccount 账号池中没有可用账号
+	ErrNoAvailableAccount = errors.New("no available account in pool")
+)
+
+// ClassifyError 将 valuescan 归为 ErrUnauthorized / ErrRateLimited 的错误映射为 ErrTokenRejected / ErrThrottled，其余错误原样返回
+// 业务码分类只在 valuescan 客户端完成（见 valuescan.WithErrorCodes），返回的错误仍包装原错误
+func ClassifyError(err error) error {
+	switch {
+	case errors.Is(err, valuescan.ErrUnauthorized):
+		return fmt.Errorf("%w: %w", ErrTokenRejected, err)
+	case errors.Is(err, valuescan.ErrRateLimited):
+		return fmt.Errorf("%w: %w", ErrThrottled, err)
+	default:
+		return err
+	}
+}
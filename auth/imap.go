@@ -0,0 +1,432 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/config"
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+)
+
+const (
+	// DefaultCodePattern 默认的验证码提取正则
+	DefaultCodePattern = `\b(\d{6})\b`
+
+	defaultIMAPPollInterval = config.DefaultIMAPPollSeconds * time.Second
+	defaultIMAPTimeout      = config.DefaultIMAPTimeoutSeconds * time.Second
+)
+
+var (
+	htmlStylePattern = regexp.MustCompile(`(?is)<(style|script)[^>]*>.*?</(style|script)>`)
+	htmlTagPattern   = regexp.MustCompile(`(?s)<[^>]*>`)
+	literalPattern   = regexp.MustCompile(`\{(\d+)\}$`)
+	uidNextPattern   = regexp.MustCompile(`[\[(]UIDNEXT (\d+)[\])]`)
+)
+
+// IMAPCodeSource 轮询 IMAP 邮箱读取验证码邮件
+type IMAPCodeSource struct {
+	cfg     config.IMAPConfig
+	pattern *regexp.Regexp
+	conn    *imapConn
+	uidNext uint64
+}
+
+// NewIMAPCodeSource 创建 IMAP 验证码来源
+func NewIMAPCodeSource(cfg config.IMAPConfig) (*IMAPCodeSource, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("imap addr is not configured")
+	}
+
+	pattern := cfg.CodePattern
+	if pattern == "" {
+		pattern = DefaultCodePattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid code pattern: %w", err)
+	}
+
+	return &IMAPCodeSource{cfg: cfg, pattern: re}, nil
+}
+
+// Prepare 登录邮箱并记录当前 UIDNEXT，之后只读取新到达的邮件
+func (s *IMAPCodeSource) Prepare(ctx context.Context) error {
+	conn, err := dialIMAP(ctx, s.cfg)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+
+	if _, err := conn.command("LOGIN %s %s", imapQuote(s.cfg.Username), imapQuote(s.cfg.Password)); err != nil {
+		return fmt.Errorf("imap login failed: %w", err)
+	}
+
+	mailbox := s.cfg.Mailbox
+	if mailbox == "" {
+		mailbox = config.DefaultIMAPMailbox
+	}
+	responses, err := conn.command("SELECT %s", imapQuote(mailbox))
+	if err != nil {
+		return fmt.Errorf("imap select failed: %w", err)
+	}
+
+	uidNext, err := conn.uidNext(mailbox, responses)
+	if err != nil {
+		return err
+	}
+	s.uidNext = uidNext
+
+	logger.Log.Debug("IMAP mailbox selected", map[string]interface{}{
+		"mailbox":  mailbox,
+		"uid_next": s.uidNext,
+	})
+
+	return nil
+}
+
+// WaitForCode 轮询新邮件，直到匹配到验证码、超时或 ctx 取消
+func (s *IMAPCodeSource) WaitForCode(ctx context.Context) (string, error) {
+	if s.conn == nil {
+		return "", fmt.Errorf("imap code source not prepared")
+	}
+
+	interval := defaultIMAPPollInterval
+	if s.cfg.PollIntervalSeconds > 0 {
+		interval = time.Duration(s.cfg.PollIntervalSeconds) * time.Second
+	}
+	timeout := defaultIMAPTimeout
+	if s.cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(s.cfg.TimeoutSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		code, err := s.poll()
+		if err != nil {
+			return "", err
+		}
+		if code != "" {
+			return code, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", fmt.Errorf("timed out waiting for login code email after %s", timeout)
+			}
+			return "", ctx.Err()
+		}
+	}
+}
+
+// Close 退出登录并关闭连接
+func (s *IMAPCodeSource) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	s.conn.command("LOGOUT")
+	return s.conn.conn.Close()
+}
+
+// poll 检查一次新邮件，返回找到的验证码（没有则为空）
+func (s *IMAPCodeSource) poll() (string, error) {
+	// NOOP 让服务端推送新邮件
+	if _, err := s.conn.command("NOOP"); err != nil {
+		return "", fmt.Errorf("imap noop failed: %w", err)
+	}
+
+	criteria := fmt.Sprintf("UID %d:*", s.uidNext)
+	if s.cfg.From != "" {
+		criteria += " FROM " + imapQuote(s.cfg.From)
+	}
+	responses, err := s.conn.command("UID SEARCH %s", criteria)
+	if err != nil {
+		return "", fmt.Errorf("imap search failed: %w", err)
+	}
+
+	// 从最新的邮件开始检查
+	uids := parseSearchUIDs(responses)
+	for i := len(uids) - 1; i >= 0; i-- {
+		// "n:*" 在没有新邮件时也会返回最后一封，需要过滤
+		if uids[i] < s.uidNext {
+			continue
+		}
+
+		fetched, err := s.conn.command("UID FETCH %d BODY.PEEK[]", uids[i])
+		if err != nil {
+			return "", fmt.Errorf("imap fetch failed: %w", err)
+		}
+
+		for _, r := range fetched {
+			for _, literal := range r.literals {
+				text, err := extractMessageText(literal)
+				if err != nil {
+					logger.Log.Warn("Failed to parse login code email", map[string]interface{}{"error": err})
+					continue
+				}
+				if m := s.pattern.FindStringSubmatch(text); len(m) > 1 {
+					return m[1], nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// uidNext 返回邮箱的 UIDNEXT：优先取 SELECT 响应，没有时依次用 STATUS 与已有邮件的最大 UID 推算
+// 无法确定时返回错误，否则 "UID 0:*" 会把旧邮件中的验证码当作新的
+func (c *imapConn) uidNext(mailbox string, selected []imapResponse) (uint64, error) {
+	if uid, ok := parseUIDNext(selected); ok {
+		return uid, nil
+	}
+
+	if status, err := c.command("STATUS %s (UIDNEXT)", imapQuote(mailbox)); err == nil {
+		if uid, ok := parseUIDNext(status); ok {
+			return uid, nil
+		}
+	}
+
+	all, err := c.command("UID SEARCH ALL")
+	if err != nil {
+		return 0, fmt.Errorf("imap server reported no UIDNEXT and UID SEARCH failed: %w", err)
+	}
+	var highest uint64
+	for _, uid := range parseSearchUIDs(all) {
+		highest = max(highest, uid)
+	}
+	return highest + 1, nil
+}
+
+// parseUIDNext 从 "* OK [UIDNEXT n]" 或 "* STATUS mailbox (UIDNEXT n)" 响应中解析 UIDNEXT
+func parseUIDNext(responses []imapResponse) (uint64, bool) {
+	for _, r := range responses {
+		if m := uidNextPattern.FindStringSubmatch(r.text); m != nil {
+			if uid, err := strconv.ParseUint(m[1], 10, 64); err == nil && uid > 0 {
+				return uid, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// parseSearchUIDs 解析 "* SEARCH 1 2 3" 响应
+func parseSearchUIDs(responses []imapResponse) []uint64 {
+	var uids []uint64
+	for _, r := range responses {
+		fields := strings.Fields(r.text)
+		if len(fields) < 2 || !strings.EqualFold(fields[1], "SEARCH") {
+			continue
+		}
+		for _, f := range fields[2:] {
+			if uid, err := strconv.ParseUint(f, 10, 64); err == nil {
+				uids = append(uids, uid)
+			}
+		}
+	}
+	return uids
+}
+
+// extractMessageText 解析邮件，返回去掉 HTML 标签后的正文文本
+func extractMessageText(raw []byte) (string, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	buf.WriteString(msg.Header.Get("Subject"))
+	buf.WriteString("\n")
+	if err := appendPartText(&buf, msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body); err != nil {
+		return "", err
+	}
+
+	text := htmlStylePattern.ReplaceAllString(buf.String(), " ")
+	return htmlTagPattern.ReplaceAllString(text, " "), nil
+}
+
+// appendPartText 递归读取邮件各部分中的文本内容
+func appendPartText(buf *strings.Builder, contentType, encoding string, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := appendPartText(buf, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !strings.HasPrefix(mediaType, "text/") {
+		return nil
+	}
+
+	switch strings.ToLower(encoding) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: body})
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	buf.WriteString("\n")
+	return nil
+}
+
+// newlineStripper 去掉 base64 正文中的换行
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	count, err := n.r.Read(p)
+	kept := 0
+	for _, b := range p[:count] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// imapConn 最小化的 IMAP4rev1 客户端，只支持读取验证码所需的命令
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapResponse 一条未标记（untagged）响应及其携带的 literal 数据
+type imapResponse struct {
+	text     string
+	literals [][]byte
+}
+
+// dialIMAP 连接 IMAP 服务器并读取欢迎信息
+func dialIMAP(ctx context.Context, cfg config.IMAPConfig) (*imapConn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if cfg.TLS {
+		host, _, _ := net.SplitHostPort(cfg.Addr)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", cfg.Addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", cfg.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect imap server: %w", err)
+	}
+
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read imap greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting.text, "* OK") && !strings.HasPrefix(greeting.text, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected imap greeting: %s", greeting.text)
+	}
+
+	return c, nil
+}
+
+// command 发送带标记的命令，返回完成前收到的所有未标记响应
+func (c *imapConn) command(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+
+	c.conn.SetDeadline(time.Now().Add(time.Minute))
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var responses []imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(resp.text, tag+" ") {
+			status := strings.TrimPrefix(resp.text, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("imap command failed: %s", status)
+			}
+			return responses, nil
+		}
+		responses = append(responses, resp)
+	}
+}
+
+// readResponse 读取一条完整响应，处理 {n} 形式的 literal
+func (c *imapConn) readResponse() (imapResponse, error) {
+	var resp imapResponse
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return resp, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		resp.text += line
+
+		m := literalPattern.FindStringSubmatch(line)
+		if m == nil {
+			return resp, nil
+		}
+
+		size, _ := strconv.Atoi(m[1])
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return resp, err
+		}
+		resp.literals = append(resp.literals, literal)
+	}
+}
+
+// imapQuote 将字符串编码为 IMAP quoted string
+func imapQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cryptoSelect/fundsTask/config"
+)
+
+// fakeIMAPServer 按脚本应答的最小 IMAP 服务端，只实现 IMAPCodeSource 用到的命令
+type fakeIMAPServer struct {
+	listener net.Listener
+
+	// selectUIDNext 为 false 时 SELECT 响应不带 [UIDNEXT n]
+	selectUIDNext bool
+	// status 为 false 时 STATUS 命令返回 BAD
+	status bool
+
+	mu       sync.Mutex
+	messages []fakeMail
+}
+
+type fakeMail struct {
+	uid uint64
+	raw string
+}
+
+func newFakeIMAPServer(t *testing.T, selectUIDNext, status bool) *fakeIMAPServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeIMAPServer{listener: ln, selectUIDNext: selectUIDNext, status: status}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeIMAPServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+// deliver 投递一封带验证码的邮件，UID 依次递增
+func (s *fakeIMAPServer) deliver(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uid := uint64(len(s.messages) + 1)
+	raw := "From: noreply@valuescan.io\r\n" +
+		"Subject: Login code\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n" +
+		"<p>Your verification code is <b>" + code + "</b></p>\r\n"
+	s.messages = append(s.messages, fakeMail{uid: uid, raw: raw})
+}
+
+func (s *fakeIMAPServer) snapshot() []fakeMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMail(nil), s.messages...)
+}
+
+func (s *fakeIMAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	defer w.Flush()
+
+	fmt.Fprint(w, "* OK fake IMAP ready\r\n")
+	w.Flush()
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, command, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			fmt.Fprintf(w, "%s BAD empty command\r\n", tag)
+			w.Flush()
+			continue
+		}
+
+		messages := s.snapshot()
+		uidNext := uint64(len(messages) + 1)
+
+		switch verb := strings.ToUpper(fields[0]); {
+		case verb == "LOGIN", verb == "NOOP":
+			fmt.Fprintf(w, "%s OK %s completed\r\n", tag, verb)
+
+		case verb == "SELECT":
+			fmt.Fprintf(w, "* %d EXISTS\r\n", len(messages))
+			if s.selectUIDNext {
+				fmt.Fprintf(w, "* OK [UIDNEXT %d] Predicted next UID\r\n", uidNext)
+			}
+			fmt.Fprintf(w, "%s OK [READ-WRITE] SELECT completed\r\n", tag)
+
+		case verb == "STATUS":
+			if !s.status {
+				fmt.Fprintf(w, "%s BAD STATUS not supported\r\n", tag)
+				break
+			}
+			fmt.Fprintf(w, "* STATUS %s (UIDNEXT %d)\r\n", fields[1], uidNext)
+			fmt.Fprintf(w, "%s OK STATUS completed\r\n", tag)
+
+		case verb == "UID" && len(fields) > 2 && strings.EqualFold(fields[1], "SEARCH"):
+			fmt.Fprintf(w, "* SEARCH%s\r\n", searchUIDs(messages, fields[2:]))
+			fmt.Fprintf(w, "%s OK SEARCH completed\r\n", tag)
+
+		case verb == "UID" && len(fields) > 2 && strings.EqualFold(fields[1], "FETCH"):
+			uid, _ := strconv.ParseUint(fields[2], 10, 64)
+			for i, m := range messages {
+				if m.uid == uid {
+					fmt.Fprintf(w, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", i+1, uid, len(m.raw), m.raw)
+				}
+			}
+			fmt.Fprintf(w, "%s OK FETCH completed\r\n", tag)
+
+		case verb == "LOGOUT":
+			fmt.Fprint(w, "* BYE logging out\r\n")
+			fmt.Fprintf(w, "%s OK LOGOUT completed\r\n", tag)
+			return
+
+		default:
+			fmt.Fprintf(w, "%s BAD unknown command\r\n", tag)
+		}
+		w.Flush()
+	}
+}
+
+// searchUIDs 实现 "ALL" 与 "UID n:*" 两种条件，后者与真实服务端一样在没有新邮件时仍返回最后一封
+func searchUIDs(messages []fakeMail, criteria []string) string {
+	var b strings.Builder
+	if strings.EqualFold(criteria[0], "ALL") {
+		for _, m := range messages {
+			fmt.Fprintf(&b, " %d", m.uid)
+		}
+		return b.String()
+	}
+
+	from, _ := strconv.ParseUint(strings.TrimSuffix(criteria[1], ":*"), 10, 64)
+	var matched []uint64
+	for _, m := range messages {
+		if m.uid >= from {
+			matched = append(matched, m.uid)
+		}
+	}
+	if len(matched) == 0 && len(messages) > 0 {
+		matched = append(matched, messages[len(messages)-1].uid)
+	}
+	for _, uid := range matched {
+		fmt.Fprintf(&b, " %d", uid)
+	}
+	return b.String()
+}
+
+func newTestIMAPSource(t *testing.T, server *fakeIMAPServer) *IMAPCodeSource {
+	t.Helper()
+
+	source, err := NewIMAPCodeSource(config.IMAPConfig{
+		Addr:                server.addr(),
+		Username:            "user",
+		Password:            "secret",
+		PollIntervalSeconds: 1,
+		TimeoutSeconds:      1,
+	})
+	if err != nil {
+		t.Fatalf("NewIMAPCodeSource: %v", err)
+	}
+	t.Cleanup(func() { source.Close() })
+	return source
+}
+
+func TestIMAPCodeSourceReadsOnlyMailAfterSelect(t *testing.T) {
+	tests := []struct {
+		name          string
+		selectUIDNext bool
+		status        bool
+	}{
+		{name: "uidnext in select", selectUIDNext: true, status: true},
+		{name: "uidnext from status", selectUIDNext: false, status: true},
+		{name: "uidnext from search", selectUIDNext: false, status: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeIMAPServer(t, tt.selectUIDNext, tt.status)
+			server.deliver("111111")
+
+			source := newTestIMAPSource(t, server)
+			if err := source.Prepare(context.Background()); err != nil {
+				t.Fatalf("Prepare: %v", err)
+			}
+			if source.uidNext != 2 {
+				t.Fatalf("uidNext = %d, want 2", source.uidNext)
+			}
+
+			server.deliver("222222")
+
+			code, err := source.WaitForCode(context.Background())
+			if err != nil {
+				t.Fatalf("WaitForCode: %v", err)
+			}
+			if code != "222222" {
+				t.Fatalf("code = %q, want 222222", code)
+			}
+		})
+	}
+}
+
+func TestIMAPCodeSourceIgnoresStaleMail(t *testing.T) {
+	server := newFakeIMAPServer(t, false, false)
+	server.deliver("111111")
+
+	source := newTestIMAPSource(t, server)
+	if err := source.Prepare(context.Background()); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+
+	code, err := source.WaitForCode(context.Background())
+	if err == nil {
+		t.Fatalf("WaitForCode returned stale code %q, want timeout", code)
+	}
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+)
+
+// TokenManager 进程内共享的令牌管理器
+// 并发安全地下发当前令牌，并把并发的续期请求合并为一次刷新或登录
+type TokenManager struct {
+	service *AuthService
+
+	mu     sync.RWMutex
+	tokens *TokenPair
+
+	// renewing 为进行中的续期，同一时刻只有一个协程在续期，其余协程等待并复用其结果（包括失败）
+	callMu   sync.Mutex
+	renewing *renewCall
+}
+
+// renewCall 一次进行中的续期，done 关闭后 tokens 与 err 可读
+type renewCall struct {
+	done   chan struct{}
+	tokens *TokenPair
+	err    error
+	// cancelled 表示发起续期的协程被取消，结果不代表账号状态，等待者需要重新续期
+	cancelled bool
+}
+
+// NewTokenManager 创建令牌管理器，tokens 为空时首次使用会加载缓存或登录
+func NewTokenManager(service *AuthService, tokens *TokenPair) *TokenManager {
+	return &TokenManager{
+		service: service,
+		tokens:  tokens,
+	}
+}
+
+// Account 返回令牌所属的账号
+func (m *TokenManager) Account() string {
+	return m.service.Account()
+}
+
+// Tokens 返回当前持有的令牌对（可能为空或已过期）
+func (m *TokenManager) Tokens() *TokenPair {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tokens
+}
+
+// Token 返回当前有效的访问令牌，即将过期时自动续期
+func (m *TokenManager) Token(ctx context.Context) (string, error) {
+	current := m.Tokens()
+	if current != nil && current.IsValid() {
+		return current.AccountToken, nil
+	}
+
+	tokens, err := m.renew(ctx, current, false)
+	if err != nil {
+		return "", err
+	}
+	return tokens.AccountToken, nil
+}
+
+// Renew 在 stale 令牌被服务端拒绝后强制续期
+// 如果其他协程已经完成了续期，直接返回新的令牌而不会再次请求
+func (m *TokenManager) Renew(ctx context.Context, stale string) (string, error) {
+	current := m.Tokens()
+	if current != nil && current.AccountToken != stale {
+		return current.AccountToken, nil
+	}
+
+	tokens, err := m.renew(ctx, current, true)
+	if err != nil {
+		return "", err
+	}
+	return tokens.AccountToken, nil
+}
+
+// renew 续期令牌；stale 为调用方看到的令牌对，force 表示即使未过期也要续期
+// 已有续期进行中时等待其完成并返回同一结果，失败时等待者同样收到该错误，不会逐个重试
+func (m *TokenManager) renew(ctx context.Context, stale *TokenPair, force bool) (*TokenPair, error) {
+	for {
+		m.callMu.Lock()
+		if call := m.renewing; call != nil {
+			m.callMu.Unlock()
+
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if call.cancelled {
+				continue
+			}
+			return call.tokens, call.err
+		}
+
+		// 调用方读取令牌之后其他协程可能已经完成续期
+		current := m.Tokens()
+		if current != nil && current.IsValid() && (current != stale || !force) {
+			m.callMu.Unlock()
+			return current, nil
+		}
+
+		call := &renewCall{done: make(chan struct{})}
+		m.renewing = call
+		m.callMu.Unlock()
+
+		call.tokens, call.err = m.doRenew(ctx, current, force)
+		call.cancelled = call.err != nil && ctx.Err() != nil
+
+		m.callMu.Lock()
+		m.renewing = nil
+		m.callMu.Unlock()
+		close(call.done)
+
+		return call.tokens, call.err
+	}
+}
+
+// doRenew 执行一次续期：没有令牌时加载缓存或登录，否则刷新或重新登录
+func (m *TokenManager) doRenew(ctx context.Context, current *TokenPair, force bool) (*TokenPair, error) {
+	var (
+		tokens *TokenPair
+		err    error
+	)
+	if current == nil {
+		tokens, err = m.service.LoadTokens(ctx)
+	} else {
+		tokens, err = m.service.RenewTokens(ctx, current)
+	}
+	// 调用方取消不代表账号不可用，不包装为 ErrRenewFailed，避免账号被移出轮换
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	if err != nil {
+		logger.Log.Error("Token renewal failed", map[string]interface{}{
+			"account": MaskAccount(m.Account()),
+			"error":   err,
+		})
+		return nil, fmt.Errorf("%w: %w", ErrRenewFailed, err)
+	}
+
+	m.mu.Lock()
+	m.tokens = tokens
+	m.mu.Unlock()
+
+	logger.Log.Info("Token renewed", map[string]interface{}{
+		"account":    MaskAccount(m.Account()),
+		"expires_at": tokens.ExpiresAt,
+		"forced":     force,
+	})
+
+	return tokens, nil
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/config"
+	"github.com/cryptoSelect/fundsTask/valuescan"
+)
+
+func TestTokenManagerSharesRenewalResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		refreshOK  bool
+		wantToken  string
+		wantErr    error
+		wantLogins int64
+	}{
+		{name: "refresh succeeds", refreshOK: true, wantToken: "new-token"},
+		{name: "refresh and re-login fail", refreshOK: false, wantErr: ErrRenewFailed, wantLogins: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var refreshes, logins atomic.Int64
+			release := make(chan struct{})
+
+			endpoints := valuescan.DefaultEndpoints()
+			endpoints.Refresh = "/api/authority/refresh"
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST "+endpoints.Refresh, func(w http.ResponseWriter, r *http.Request) {
+				refreshes.Add(1)
+				// 等所有协程都进入续期后再应答
+				<-release
+				if !tt.refreshOK {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"code": valuescan.CodeSuccess,
+					"msg":  "success",
+					"data": valuescan.LoginData{AccountToken: "new-token", RefreshToken: "refresh-token"},
+				})
+			})
+			mux.HandleFunc("POST "+endpoints.Login, func(w http.ResponseWriter, r *http.Request) {
+				logins.Add(1)
+				json.NewEncoder(w).Encode(map[string]interface{}{"code": 4000, "msg": "invalid code", "data": ""})
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			service := &AuthService{
+				client: valuescan.NewClient(server.URL,
+					valuescan.WithEndpoints(endpoints),
+					valuescan.WithRetryPolicy(valuescan.RetryPolicy{MaxAttempts: 1}),
+				),
+				account: config.LoginConfig{PhoneOrEmail: "user@example.com"},
+			}
+			manager := NewTokenManager(service, NewTokenPair("old-token", "refresh-token"))
+
+			const callers = 8
+			var started, done sync.WaitGroup
+			started.Add(callers)
+			tokens := make([]string, callers)
+			errs := make([]error, callers)
+			for i := range callers {
+				done.Go(func() {
+					started.Done()
+					tokens[i], errs[i] = manager.Renew(context.Background(), "old-token")
+				})
+			}
+			started.Wait()
+			time.Sleep(100 * time.Millisecond)
+			close(release)
+			done.Wait()
+
+			for i := range callers {
+				if tt.wantErr != nil {
+					if !errors.Is(errs[i], tt.wantErr) {
+						t.Fatalf("caller %d: err = %v, want %v", i, errs[i], tt.wantErr)
+					}
+					continue
+				}
+				if errs[i] != nil || tokens[i] != tt.wantToken {
+					t.Fatalf("caller %d: token = %q, err = %v, want %q", i, tokens[i], errs[i], tt.wantToken)
+				}
+			}
+			if got := refreshes.Load(); got != 1 {
+				t.Fatalf("refresh calls = %d, want 1", got)
+			}
+			if got := logins.Load(); got != tt.wantLogins {
+				t.Fatalf("login calls = %d, want %d", got, tt.wantLogins)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/config"
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+	"github.com/cryptoSelect/fundsTask/valuescan"
+)
+
+const (
+	// DefaultThrottleCooldown 账号被限流后默认暂停使用的时长
+	DefaultThrottleCooldown = config.DefaultThrottleCooldownSeconds * time.Second
+	// DefaultDisableCooldown 账号续期失败后默认暂停使用的时长
+	DefaultDisableCooldown = config.DefaultDisableCooldownSeconds * time.Second
+)
+
+// AccountPool 多账号会话池，按轮询方式分配请求
+// 被限流或续期失败的账号会在冷却期内自动移出轮换
+type AccountPool struct {
+	mu      sync.Mutex
+	members []*poolMember
+	next    int
+}
+
+// poolMember 账号池成员
+type poolMember struct {
+	manager        *TokenManager
+	suspendedUntil time.Time
+	reason         string
+}
+
+// NewAccountPool 根据登录配置创建账号池，所有账号共用同一个 ValueScan 客户端
+func NewAccountPool(client *valuescan.Client, accounts []config.LoginConfig) *AccountPool {
+	pool := &AccountPool{}
+	for _, account := range accounts {
+		pool.members = append(pool.members, &poolMember{
+			manager: NewTokenManager(NewAccountAuthService(client, account), nil),
+		})
+	}
+	return pool
+}
+
+// Size 返回账号池中的账号数量
+func (p *AccountPool) Size() int {
+	return len(p.members)
+}
+
+// Init 为每个账号加载缓存会话或登录，至少一个账号可用时返回成功
+func (p *AccountPool) Init(ctx context.Context) error {
+	ready := 0
+	for _, member := range p.members {
+		if _, err := member.manager.Token(ctx); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			p.Disable(member.manager, err)
+			continue
+		}
+		ready++
+	}
+
+	logger.Log.Info("Account pool initialized", map[string]interface{}{
+		"total": len(p.members),
+		"ready": ready,
+	})
+
+	if ready == 0 {
+		return ErrNoAvailableAccount
+	}
+	return nil
+}
+
+// Acquire 轮询选取下一个可用账号，跳过冷却中的账号
+func (p *AccountPool) Acquire() (*TokenManager, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for i := 0; i < len(p.members); i++ {
+		member := p.members[(p.next+i)%len(p.members)]
+		if now.Before(member.suspendedUntil) {
+			continue
+		}
+		p.next = (p.next + i + 1) % len(p.members)
+		return member.manager, nil
+	}
+
+	return nil, ErrNoAvailableAccount
+}
+
+// MarkThrottled 账号被限流，冷却期内不再分配
+func (p *AccountPool) MarkThrottled(manager *TokenManager, err error) {
+	cooldown := DefaultThrottleCooldown
+	if seconds := config.Current().Pool.ThrottleCooldownSeconds; seconds > 0 {
+		cooldown = time.Duration(seconds) * time.Second
+	}
+	p.suspend(manager, cooldown, err)
+}
+
+// Disable 账号续期失败（疑似被封禁或登录码失效），较长时间内不再分配
+func (p *AccountPool) Disable(manager *TokenManager, err error) {
+	cooldown := DefaultDisableCooldown
+	if seconds := config.Current().Pool.DisableCooldownSeconds; seconds > 0 {
+		cooldown = time.Duration(seconds) * time.Second
+	}
+	p.suspend(manager, cooldown, err)
+}
+
+// suspend 暂停账号直到冷却结束
+func (p *AccountPool) suspend(manager *TokenManager, cooldown time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, member := range p.members {
+		if member.manager != manager {
+			continue
+		}
+		member.suspendedUntil = time.Now().Add(cooldown)
+		member.reason = fmt.Sprint(err)
+
+		logger.Log.Warn("Account removed from rotation", map[string]interface{}{
+			"account":          MaskAccount(manager.Account()),
+			"cooldown_seconds": int(cooldown.Seconds()),
+			"reason":           member.reason,
+		})
+		return
+	}
+}
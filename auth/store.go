@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/config"
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+
+	"github.com/cryptoSelect/public/database"
+	"gorm.io/gorm"
+)
+
+const (
+	TokenStoreFile = "file"
+	TokenStoreDB   = "db"
+)
+
+// TokenStore 令牌持久化存储，按账号保存令牌对
+type TokenStore interface {
+	// Load 读取账号缓存的令牌对，不存在时返回 nil, nil
+	Load(ctx context.Context, account string) (*TokenPair, error)
+	// Save 保存账号的令牌对
+	Save(ctx context.Context, account string, tp *TokenPair) error
+}
+
+// NewTokenStore 根据配置创建令牌存储，未配置时返回 nil（不持久化）
+func NewTokenStore() TokenStore {
+	storeCfg := config.Current().Auth.TokenStore
+	switch storeCfg.Type {
+	case TokenStoreFile:
+		return NewFileTokenStore(storeCfg.Path)
+	case TokenStoreDB:
+		return NewDBTokenStore()
+	case "":
+		return nil
+	default:
+		logger.Log.Warn("Unknown token store type, tokens will not be persisted", map[string]interface{}{
+			"type": storeCfg.Type,
+		})
+		return nil
+	}
+}
+
+// fileLocks 按文件路径共享的锁：每个账号有各自的 FileTokenStore，但读改写的是同一个文件
+var fileLocks sync.Map // map[string]*sync.Mutex
+
+// fileLock 返回文件路径对应的锁
+func fileLock(path string) *sync.Mutex {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	mu, _ := fileLocks.LoadOrStore(path, &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
+
+// FileTokenStore 基于 JSON 文件的令牌存储，同一文件的多个实例共享一把锁
+type FileTokenStore struct {
+	path string
+	mu   *sync.Mutex
+}
+
+// NewFileTokenStore 创建文件令牌存储
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path, mu: fileLock(path)}
+}
+
+// Load 从文件读取令牌对
+func (s *FileTokenStore) Load(_ context.Context, account string) (*TokenPair, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens[account], nil
+}
+
+// Save 将令牌对写入文件（先写临时文件再重命名，避免写一半）
+func (s *FileTokenStore) Save(_ context.Context, account string, tp *TokenPair) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	tokens[account] = tp
+
+	data, err := json.MarshalIndent(tokens, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tokens: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create token store directory: %w", err)
+	}
+
+	// 临时文件与目标文件在同一目录，重命名才是原子的
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create token store temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace token store: %w", err)
+	}
+
+	return nil
+}
+
+// readAll 读取文件中的全部令牌，文件不存在时返回空集合
+func (s *FileTokenStore) readAll() (map[string]*TokenPair, error) {
+	tokens := make(map[string]*TokenPair)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token store: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// TokenRecord 数据库中保存的令牌记录
+type TokenRecord struct {
+	ID               uint      `gorm:"primaryKey;comment:主键ID" json:"id"`
+	Account          string    `gorm:"uniqueIndex;comment:登录账号" json:"account"`
+	AccountToken     string    `gorm:"type:text;comment:访问令牌" json:"account_token"`
+	RefreshToken     string    `gorm:"type:text;comment:刷新令牌" json:"refresh_token"`
+	IssuedAt         int64     `gorm:"comment:签发时间戳" json:"issued_at"`
+	ExpiresAt        int64     `gorm:"comment:过期时间戳" json:"expires_at"`
+	RefreshExpiresAt int64     `gorm:"comment:刷新令牌过期时间戳" json:"refresh_expires_at"`
+	UpdatedAt        time.Time `gorm:"comment:更新时间" json:"updated_at"`
+}
+
+func (TokenRecord) TableName() string {
+	return "auth_token"
+}
+
+// DBTokenStore 基于 Postgres 的令牌存储
+type DBTokenStore struct{}
+
+// NewDBTokenStore 创建数据库令牌存储
+func NewDBTokenStore() *DBTokenStore {
+	return &DBTokenStore{}
+}
+
+// Load 从数据库读取令牌对
+func (s *DBTokenStore) Load(ctx context.Context, account string) (*TokenPair, error) {
+	var record TokenRecord
+	err := database.DB.WithContext(ctx).Where("account = ?", account).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token record: %w", err)
+	}
+
+	return &TokenPair{
+		AccountToken:     record.AccountToken,
+		RefreshToken:     record.RefreshToken,
+		IssuedAt:         record.IssuedAt,
+		ExpiresAt:        record.ExpiresAt,
+		RefreshExpiresAt: record.RefreshExpiresAt,
+	}, nil
+}
+
+// Save 将令牌对写入数据库（使用 Upsert 方式）
+func (s *DBTokenStore) Save(ctx context.Context, account string, tp *TokenPair) error {
+	// 使用 map 赋值，保证过期时间等零值字段也会被覆盖
+	record := TokenRecord{Account: account}
+	result := database.DB.WithContext(ctx).Where("account = ?", account).
+		Assign(map[string]interface{}{
+			"account_token":      tp.AccountToken,
+			"refresh_token":      tp.RefreshToken,
+			"issued_at":          tp.IssuedAt,
+			"expires_at":         tp.ExpiresAt,
+			"refresh_expires_at": tp.RefreshExpiresAt,
+		}).
+		FirstOrCreate(&record)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to save token record: %w", result.Error)
+	}
+
+	return nil
+}
@@ -1,52 +1,392 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 // LoginConfig 登录配置
 type LoginConfig struct {
-	PhoneOrEmail string `json:"phoneOrEmail"`
-	Code         string `json:"code"`
+	PhoneOrEmail string     `json:"phoneOrEmail" doc:"ValueScan 登录账号（手机号或邮箱）"`
+	Code         string     `json:"code" doc:"登录验证码，支持 env:/file:/enc: 引用；配置了令牌存储时可留空并用 login 命令引导"`
+	IMAP         IMAPConfig `json:"imap" doc:"自动读取登录验证码的邮箱（可选）"`
+}
+
+// IMAPConfig 读取登录验证码邮件的 IMAP 配置
+type IMAPConfig struct {
+	Addr                string `json:"addr" doc:"IMAP 服务器 host:port，如 imap.example.com:993；留空不启用"`
+	TLS                 bool   `json:"tls" doc:"是否使用 TLS 连接"`
+	Username            string `json:"username" doc:"邮箱用户名"`
+	Password            string `json:"password" doc:"邮箱密码，支持 env:/file:/enc: 引用"`
+	Mailbox             string `json:"mailbox" doc:"读取的邮箱文件夹"`
+	From                string `json:"from" doc:"只匹配该发件人的邮件（可选）"`
+	CodePattern         string `json:"codePattern" doc:"提取验证码的正则，第一个分组为验证码；留空匹配 6 位数字"`
+	PollIntervalSeconds int    `json:"pollIntervalSeconds" doc:"轮询新邮件的间隔秒数"`
+	TimeoutSeconds      int    `json:"timeoutSeconds" doc:"等待验证码邮件的超时秒数"`
+}
+
+// LoginConfigs 多账号登录配置，兼容单个对象与数组两种写法
+type LoginConfigs []LoginConfig
+
+// UnmarshalJSON 同时支持 {"phoneOrEmail": ...} 与 [{...}, {...}]
+func (l *LoginConfigs) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var single LoginConfig
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return err
+		}
+		*l = LoginConfigs{single}
+		return nil
+	}
+
+	var list []LoginConfig
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Primary 返回第一个账号，未配置时返回空配置
+func (l LoginConfigs) Primary() LoginConfig {
+	if len(l) == 0 {
+		return LoginConfig{}
+	}
+	return l[0]
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
-	Host     string `json:"host"`
-	Port     int    `json:"port"`
-	User     string `json:"user"`
-	Password string `json:"password"`
-	DBName   string `json:"dbName"`
-	SSLMode  string `json:"sslMode"`
+	Host     string `json:"host" doc:"PostgreSQL 地址（必填）"`
+	Port     int    `json:"port" doc:"PostgreSQL 端口"`
+	User     string `json:"user" doc:"数据库用户（必填）"`
+	Password string `json:"password" doc:"数据库密码，支持 env:/file:/enc: 引用"`
+	DBName   string `json:"dbName" doc:"数据库名（必填）"`
+	SSLMode  string `json:"sslMode" doc:"disable / allow / prefer / require / verify-ca / verify-full"`
+
+	SSLRootCert             string `json:"sslRootCert" doc:"校验服务端证书的 CA 文件路径（sslMode 为 verify-ca / verify-full 时使用）"`
+	ApplicationName         string `json:"applicationName" doc:"连接的 application_name，便于在 pg_stat_activity 中识别"`
+	TimeZone                string `json:"timeZone" doc:"会话时区"`
+	ConnectTimeoutSeconds   int    `json:"connectTimeoutSeconds" doc:"单次建立连接的超时秒数"`
+	StatementTimeoutSeconds int    `json:"statementTimeoutSeconds" doc:"单条语句的超时秒数，0 表示不限制"`
+	MaxOpenConns            int    `json:"maxOpenConns" doc:"连接池最大连接数"`
+	MaxIdleConns            int    `json:"maxIdleConns" doc:"连接池最大空闲连接数"`
+	ConnMaxLifetimeSeconds  int    `json:"connMaxLifetimeSeconds" doc:"连接最长使用秒数，超过后重建"`
+	ConnMaxIdleTimeSeconds  int    `json:"connMaxIdleTimeSeconds" doc:"空闲连接保留秒数"`
+	StartupTimeoutSeconds   int    `json:"startupTimeoutSeconds" doc:"启动时等待数据库可用的最长秒数，期间按指数退避重试"`
 }
 
-// TimerConfig 定时器配置
+// TimerConfig 定时器配置，保留以兼容已有配置文件，当前未使用
 type TimerConfig struct {
-	SkipFirstDelay     bool `json:"skipFirstDelay"`
-	ImmediateExecution bool `json:"immediateExecution"`
+	SkipFirstDelay     bool `json:"skipFirstDelay" doc:"保留字段，当前未使用"`
+	ImmediateExecution bool `json:"immediateExecution" doc:"保留字段，当前未使用"`
+}
+
+// ScheduleConfig 定时任务间隔配置
+type ScheduleConfig struct {
+	CoinInfoIntervalMinutes    int `json:"coinInfoIntervalMinutes" doc:"币种信息任务间隔（分钟，1-1440）"`
+	TradeInflowIntervalMinutes int `json:"tradeInflowIntervalMinutes" doc:"资金流向任务间隔（分钟，1-1440）"`
+}
+
+// CoinFilterConfig 币种查询与资金流向扫描的过滤条件
+type CoinFilterConfig struct {
+	Search       string   `json:"search" doc:"币种查询关键字"`
+	AllExchanges bool     `json:"allExchanges" doc:"查询所有交易所的币种，默认只查询币安上线的币种"`
+	PageSize     int      `json:"pageSize" doc:"每次查询的币种数量"`
+	Watchlist    []string `json:"watchlist" doc:"只扫描这些币种（symbol）的资金流向，留空扫描全部"`
+}
+
+// ValueScanConfig ValueScan 接口地址与公共请求头
+type ValueScanConfig struct {
+	BaseURL   string            `json:"baseURL" doc:"接口根地址，可指向预发环境、缓存代理或本地模拟服务"`
+	Paths     ValueScanPaths    `json:"paths" doc:"各接口路径"`
+	Headers   map[string]string `json:"headers" doc:"附加到每个请求的请求头；配置后整体替换默认值"`
+	Retry     RetryConfig       `json:"retry" doc:"请求失败时的重试策略"`
+	RateLimit RateLimitConfig   `json:"rateLimit" doc:"客户端限速（令牌桶），请求排队等待的时间会记录在日志中"`
+	Breaker   BreakerConfig     `json:"circuitBreaker" doc:"按接口熔断，上游故障时快速失败"`
+	Traffic   TrafficConfig     `json:"traffic" doc:"录制或回放上游请求，用于离线复现与制作回归数据"`
+	Faults    FaultsConfig      `json:"faults" doc:"故障注入，用于在 dev 模式下验证各类错误处理；prod 模式不允许开启"`
+}
+
+// ValueScanPaths ValueScan 各接口路径
+type ValueScanPaths struct {
+	Login       string `json:"login" doc:"登录"`
+	Refresh     string `json:"refresh" doc:"刷新令牌；接口约定未经确认，没有默认值，留空则续期时直接重新登录"`
+	SendCode    string `json:"sendCode" doc:"发送验证码；接口约定未经确认，没有默认值，留空则无法使用 login bootstrap"`
+	QueryCoin   string `json:"queryCoin" doc:"查询币种"`
+	TradeInflow string `json:"tradeInflow" doc:"查询资金流向（使用 accessToken 请求头携带凭证）"`
+}
+
+// RetryConfig ValueScan 请求重试配置
+type RetryConfig struct {
+	MaxAttempts      int   `json:"maxAttempts" doc:"每次调用最多请求的次数（含第一次），1 表示不重试"`
+	BaseDelaySeconds int   `json:"baseDelaySeconds" doc:"第一次重试前的退避时间，之后每次翻倍并加随机抖动"`
+	MaxDelaySeconds  int   `json:"maxDelaySeconds" doc:"单次退避的上限"`
+	BudgetSeconds    int   `json:"budgetSeconds" doc:"每次调用用于等待重试的总时间，Retry-After 超出剩余时间时直接放弃"`
+	RetryableCodes   []int `json:"retryableCodes" doc:"可重试的业务码；超时、连接重置、HTTP 5xx 与 429 总是重试"`
+}
+
+// RateLimitConfig ValueScan 请求限速配置
+type RateLimitConfig struct {
+	Login       LimitConfig `json:"login" doc:"登录接口，所有账号共享"`
+	Refresh     LimitConfig `json:"refresh" doc:"刷新令牌接口，所有账号共享"`
+	SendCode    LimitConfig `json:"sendCode" doc:"发送验证码接口，所有账号共享"`
+	QueryCoin   LimitConfig `json:"queryCoin" doc:"查询币种接口，所有账号共享"`
+	TradeInflow LimitConfig `json:"tradeInflow" doc:"查询资金流向接口，所有账号共享"`
+	Account     LimitConfig `json:"account" doc:"每个账号所有接口合计"`
+}
+
+// LimitConfig 令牌桶参数
+type LimitConfig struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond" doc:"每秒请求数，0 表示不限速"`
+	Burst             int     `json:"burst" doc:"允许的突发请求数，留空为 1"`
+}
+
+// BreakerConfig ValueScan 接口熔断配置
+type BreakerConfig struct {
+	FailureThreshold int `json:"failureThreshold" doc:"连续多少次上游故障（超时、连接错误、HTTP 5xx）后熔断"`
+	CooldownSeconds  int `json:"cooldownSeconds" doc:"熔断后多久放行一个探测请求"`
+}
+
+// TrafficConfig ValueScan 请求录制与回放配置
+type TrafficConfig struct {
+	Mode string `json:"mode" doc:"留空正常请求；record 将每次请求与响应写入 dir（隐藏认证头与令牌）；replay 从 dir 读取录制的响应，不访问网络"`
+	Dir  string `json:"dir" doc:"录制文件目录"`
+}
+
+// FaultsConfig ValueScan 故障注入配置
+type FaultsConfig struct {
+	Enabled     bool        `json:"enabled" doc:"开启故障注入，仅 dev 模式可用"`
+	Login       FaultConfig `json:"login" doc:"登录接口"`
+	Refresh     FaultConfig `json:"refresh" doc:"刷新令牌接口"`
+	SendCode    FaultConfig `json:"sendCode" doc:"发送验证码接口"`
+	QueryCoin   FaultConfig `json:"queryCoin" doc:"查询币种接口"`
+	TradeInflow FaultConfig `json:"tradeInflow" doc:"查询资金流向接口"`
+}
+
+// FaultConfig 单个接口的故障注入规则，各比例取值 0-1
+type FaultConfig struct {
+	LatencyMs         int     `json:"latencyMs" doc:"每个请求增加的延迟（毫秒）"`
+	LatencyJitterMs   int     `json:"latencyJitterMs" doc:"在延迟基础上随机增加的抖动上限（毫秒）"`
+	ErrorRate         float64 `json:"errorRate" doc:"直接返回连接重置错误的比例"`
+	StatusRate        float64 `json:"statusRate" doc:"直接返回 status 状态码的比例"`
+	Status            int     `json:"status" doc:"注入的 HTTP 状态码，留空为 503；配置 429 模拟限流风暴"`
+	RetryAfterSeconds int     `json:"retryAfterSeconds" doc:"注入状态码时附带的 Retry-After，0 不附带"`
+	TruncateRate      float64 `json:"truncateRate" doc:"响应体读到一半中断的比例"`
+	MalformedRate     float64 `json:"malformedRate" doc:"响应体替换为无法解析的 JSON 的比例"`
+	EmptyDataRate     float64 `json:"emptyDataRate" doc:"响应中 data 替换为空字符串的比例"`
+}
+
+// HealthConfig 健康检查接口配置
+type HealthConfig struct {
+	Addr string `json:"addr" doc:"健康检查 HTTP 监听地址，如 :8081；留空不启动"`
+}
+
+// TokenStoreConfig 令牌持久化配置
+type TokenStoreConfig struct {
+	Type string `json:"type" doc:"file / db，留空则不持久化"`
+	Path string `json:"path" doc:"type 为 file 时的文件路径"`
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	Mode                 string           `json:"mode" doc:"login / apiKey / none"`
+	APIKey               string           `json:"apiKey" doc:"mode 为 apiKey 时使用的固定凭证，支持 env:/file:/enc: 引用"`
+	RefreshMarginSeconds int              `json:"refreshMarginSeconds" doc:"令牌到期前提前续期的秒数"`
+	TokenStore           TokenStoreConfig `json:"tokenStore" doc:"令牌持久化"`
+	TokenInvalidCodes    []int            `json:"tokenInvalidCodes" doc:"表示令牌失效的业务码，默认 401/403；ValueScan 的其它令牌失效业务码需自行补充"`
+	ThrottleCodes        []int            `json:"throttleCodes" doc:"表示账号被限流的业务码"`
+}
+
+// PoolConfig 多账号轮换配置
+type PoolConfig struct {
+	ThrottleCooldownSeconds int `json:"throttleCooldownSeconds" doc:"账号被限流后暂停使用的秒数"`
+	DisableCooldownSeconds  int `json:"disableCooldownSeconds" doc:"账号续期失败（疑似封禁）后暂停使用的秒数"`
 }
 
 // Config 应用配置
 type Config struct {
-	Mode     string         `json:"mode"`
-	Login    LoginConfig    `json:"login"`
-	Database DatabaseConfig `json:"database"`
-	Timer    TimerConfig    `json:"timer"`
+	Mode      string           `json:"mode" doc:"dev / prod；prod 按 schedule 对齐等待后执行任务"`
+	LogLevel  string           `json:"logLevel" doc:"debug / info / warn / error，留空时 dev 为 debug、prod 为 info（可热更新）"`
+	ValueScan ValueScanConfig  `json:"valuescan" doc:"ValueScan 接口"`
+	Login     LoginConfigs     `json:"login" doc:"登录账号，可配置多个账号轮换使用"`
+	Auth      AuthConfig       `json:"auth" doc:"认证方式与令牌管理"`
+	Pool      PoolConfig       `json:"pool" doc:"多账号轮换"`
+	Database  DatabaseConfig   `json:"database" doc:"PostgreSQL 连接"`
+	Timer     TimerConfig      `json:"timer" doc:"定时器（保留字段，当前未使用）"`
+	Schedule  ScheduleConfig   `json:"schedule" doc:"任务间隔，从每天 00:00 起按间隔对齐执行（可热更新）"`
+	Coins     CoinFilterConfig `json:"coins" doc:"币种查询与资金流向扫描的过滤条件（可热更新）"`
+	Health    HealthConfig     `json:"health" doc:"健康检查接口，输出各接口熔断状态"`
+
+	SecretKeyFile string `json:"secretKeyFile" doc:"解密 enc: 敏感值所用的密钥文件，也可用 FUNDSTASK_SECRET_KEY_FILE 指定"`
 }
 
-var Cfg *Config
+// current 当前生效的配置，热更新与运行时设置会整体替换，不修改已发布的配置
+var current atomic.Pointer[Config]
 
-// Init 初始化配置
-func Init() {
-	configFile := "config/config.json"
-	data, err := os.ReadFile(configFile)
+// Current 返回当前生效的配置，未初始化时返回 nil
+// 返回值是只读快照，需要多个字段保持一致时应只调用一次
+func Current() *Config {
+	return current.Load()
+}
+
+const (
+	// DefaultConfigFile 未指定时使用的配置文件路径，不存在时依次尝试同名的 .yaml / .yml / .toml 文件
+	DefaultConfigFile = "config/config.json"
+	// ConfigFileEnv 指定配置文件路径的环境变量
+	ConfigFileEnv = "FUNDSTASK_CONFIG"
+	// ConfigOverlayEnv 指定覆盖文件的环境变量，多个文件用逗号分隔
+	ConfigOverlayEnv = "FUNDSTASK_CONFIG_OVERLAY"
+)
+
+// defaultConfigFiles 未指定配置文件时按顺序查找的路径
+var defaultConfigFiles = []string{DefaultConfigFile, "config/config.yaml", "config/config.yml", "config/config.toml"}
+
+// LoadOptions 配置加载选项
+type LoadOptions struct {
+	Path     string   // 基础配置文件，留空时依次使用 FUNDSTASK_CONFIG 与默认路径
+	Overlays []string // 按顺序叠加在基础配置上的覆盖文件（如按环境区分的文件）
+}
+
+// Init 加载并校验配置，成功后设置当前配置
+func Init(opts LoadOptions) error {
+	cfg, err := Load(opts)
 	if err != nil {
-		panic("Failed to read config file: " + err.Error())
+		return err
+	}
+
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	fileCfg = cfg
+	current.Store(cfg)
+	return nil
+}
+
+// resolvePaths 返回基础配置文件与覆盖文件的实际路径
+func (opts LoadOptions) resolvePaths() (string, []string) {
+	path := opts.Path
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
 	}
+	if path == "" {
+		path = DefaultConfigFile
+		for _, candidate := range defaultConfigFiles {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+
+	raw := opts.Overlays
+	if len(raw) == 0 {
+		if env := os.Getenv(ConfigOverlayEnv); env != "" {
+			raw = strings.Split(env, ",")
+		}
+	}
+	overlays := make([]string, 0, len(raw))
+	for _, overlay := range raw {
+		overlays = append(overlays, strings.TrimSpace(overlay))
+	}
+
+	return path, overlays
+}
 
-	err = json.Unmarshal(data, &Cfg)
+// explicitPath 判断是否通过参数或环境变量指定了基础配置文件，指定的文件必须存在
+func (opts LoadOptions) explicitPath() bool {
+	return opts.Path != "" || os.Getenv(ConfigFileEnv) != ""
+}
+
+// Load 加载配置：基础文件 -> 覆盖文件 -> 环境变量 -> 敏感字段引用解析 -> 默认值与校验
+func Load(opts LoadOptions) (*Config, error) {
+	path, overlays := opts.resolvePaths()
+
+	// 未指定配置文件且默认文件不存在时从空配置开始，完全由覆盖文件与环境变量提供（如容器部署）
+	merged, err := readConfigFile(path)
+	if errors.Is(err, os.ErrNotExist) && !opts.explicitPath() {
+		merged = make(map[string]interface{})
+	} else if err != nil {
+		return nil, err
+	}
+	for _, overlayPath := range overlays {
+		overlay, err := readConfigFile(overlayPath)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeConfigMaps(merged, overlay)
+	}
+
+	// 合并后的结果重新按 Config 结构解析
+	data, err := json.Marshal(merged)
 	if err != nil {
-		panic("Failed to parse config file: " + err.Error())
+		return nil, fmt.Errorf("failed to marshal merged config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if err := applyEnvOverrides(&cfg); err != nil {
+		return nil, err
+	}
+
+	// 解析敏感字段中的 env:/file:/enc: 引用
+	if err := resolveSecrets(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to resolve config secrets: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	return &cfg, nil
+}
+
+// readConfigFile 读取配置文件（JSON / YAML / TOML）为通用 map，便于逐层合并
+func readConfigFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	// 按扩展名选择格式，统一解析为 map 后按 JSON 规则处理
+	var values map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		err = json.Unmarshal(data, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// mergeConfigMaps 将 overlay 合并进 base：对象逐字段递归合并，数组与标量整体替换
+func mergeConfigMaps(base, overlay map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{})
+	}
+	for key, value := range overlay {
+		baseChild, baseIsMap := base[key].(map[string]interface{})
+		overlayChild, overlayIsMap := value.(map[string]interface{})
+		if baseIsMap && overlayIsMap {
+			base[key] = mergeConfigMaps(baseChild, overlayChild)
+			continue
+		}
+		base[key] = value
 	}
+	return base
 }
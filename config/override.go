@@ -0,0 +1,253 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix 覆盖配置字段的环境变量前缀，如 FUNDSTASK_DATABASE_HOST 覆盖 database.host
+const EnvPrefix = "FUNDSTASK_"
+
+// maxEnvSliceGrowth 环境变量最多能在结构体切片现有长度之后新增的元素数，防止写错的大下标分配大量空元素
+const maxEnvSliceGrowth = 16
+
+// fieldVisitor 遍历配置叶子字段时的回调，path 为各级 json 字段名（数组元素为下标）
+type fieldVisitor func(path []string, field reflect.Value) error
+
+// walkFields 递归遍历结构体的叶子字段（基本类型与基本类型切片）
+func walkFields(v reflect.Value, path []string, visit fieldVisitor) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return walkFields(v.Elem(), path, visit)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := jsonFieldName(t.Field(i))
+			if name == "" {
+				continue
+			}
+			if err := walkFields(v.Field(i), appendPath(path, name), visit); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Slice:
+		// 结构体切片（如多账号登录配置）逐个元素遍历，基本类型切片作为叶子字段
+		if v.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < v.Len(); i++ {
+				if err := walkFields(v.Index(i), appendPath(path, strconv.Itoa(i)), visit); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return visit(path, v)
+
+	default:
+		return visit(path, v)
+	}
+}
+
+// appendPath 复制并追加路径，避免共享底层数组
+func appendPath(path []string, name string) []string {
+	next := make([]string, len(path), len(path)+1)
+	copy(next, path)
+	return append(next, name)
+}
+
+// jsonFieldName 返回字段的 json 名称，忽略的字段返回空字符串
+func jsonFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := strings.Split(field.Tag.Get("json"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	if tag == "" {
+		return field.Name
+	}
+	return tag
+}
+
+// setFieldFromString 将字符串解析为字段的类型并赋值，切片使用逗号分隔，
+// map 使用 JSON 对象或逗号分隔的 key=value（如 "X-A=1,X-B=2"）
+func setFieldFromString(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		if strings.TrimSpace(value) != "" {
+			parts = strings.Split(value, ",")
+		}
+		slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setFieldFromString(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	case reflect.Map:
+		return setMapFromString(field, value)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// setMapFromString 解析 JSON 对象或逗号分隔的 key=value，整体替换 map
+func setMapFromString(field reflect.Value, value string) error {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "{") {
+		m := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(value), m.Interface()); err != nil {
+			return err
+		}
+		field.Set(m.Elem())
+		return nil
+	}
+
+	if field.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("unsupported map key type %s", field.Type().Key())
+	}
+	m := reflect.MakeMap(field.Type())
+	if value != "" {
+		for _, pair := range strings.Split(value, ",") {
+			key, val, ok := strings.Cut(pair, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return fmt.Errorf("invalid map entry %q, want key=value", pair)
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setFieldFromString(elem, strings.TrimSpace(val)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(field.Type().Key()), elem)
+		}
+	}
+	field.Set(m)
+	return nil
+}
+
+// applyEnvOverrides 使用 FUNDSTASK_ 前缀的环境变量覆盖配置字段
+// 字段名按 json 名称转为大写下划线形式，如 database.dbName -> FUNDSTASK_DATABASE_DB_NAME、login[0].code -> FUNDSTASK_LOGIN_0_CODE
+// 结构体切片会按环境变量中出现的最大下标补齐元素，因此只用环境变量也能配置账号
+func applyEnvOverrides(cfg *Config) error {
+	if err := growSlicesFromEnv(reflect.ValueOf(cfg), nil, os.Environ()); err != nil {
+		return err
+	}
+	return walkFields(reflect.ValueOf(cfg), nil, func(path []string, field reflect.Value) error {
+		name := EnvName(path)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		if err := setFieldFromString(field, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// EnvName 返回覆盖指定字段路径的环境变量名
+func EnvName(path []string) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = toUpperSnake(p)
+	}
+	return EnvPrefix + strings.Join(parts, "_")
+}
+
+// toUpperSnake 将 camelCase 转为 UPPER_SNAKE，连续大写视为一个词（如 baseURL -> BASE_URL）
+func toUpperSnake(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// growSlicesFromEnv 递归查找结构体切片，按 FUNDSTASK_<路径>_<下标>_ 形式的环境变量补齐元素
+// 下标超过切片原有长度加 maxEnvSliceGrowth 时返回错误
+func growSlicesFromEnv(v reflect.Value, path []string, environ []string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return growSlicesFromEnv(v.Elem(), path, environ)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if name := jsonFieldName(t.Field(i)); name != "" {
+				if err := growSlicesFromEnv(v.Field(i), appendPath(path, name), environ); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		prefix := EnvName(path) + "_"
+		limit := v.Len() + maxEnvSliceGrowth
+		for _, kv := range environ {
+			name, _, _ := strings.Cut(kv, "=")
+			rest, ok := strings.CutPrefix(name, prefix)
+			if !ok {
+				continue
+			}
+			index, _, _ := strings.Cut(rest, "_")
+			n, err := strconv.Atoi(index)
+			if err != nil || n < v.Len() {
+				continue
+			}
+			if n >= limit {
+				return fmt.Errorf("invalid index in %s: %d exceeds the maximum of %d", name, n, limit-1)
+			}
+			v.Set(reflect.AppendSlice(v, reflect.MakeSlice(v.Type(), n+1-v.Len(), n+1-v.Len())))
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := growSlicesFromEnv(v.Index(i), appendPath(path, strconv.Itoa(i)), environ); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
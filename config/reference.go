@@ -0,0 +1,88 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Reference 根据 Config 结构、字段的 doc 标签与默认值生成带注释的 YAML 参考配置
+func Reference() ([]byte, error) {
+	cfg := Config{Login: LoginConfigs{{}}}
+	cfg.applyDefaults()
+	// 以下默认值只在启用对应功能时补全，参考配置中直接列出
+	cfg.Login[0].IMAP.Mailbox = DefaultIMAPMailbox
+	cfg.Login[0].IMAP.PollIntervalSeconds = DefaultIMAPPollSeconds
+	cfg.Login[0].IMAP.TimeoutSeconds = DefaultIMAPTimeoutSeconds
+	cfg.Auth.TokenStore.Path = DefaultTokenStorePath
+
+	root, err := referenceNode(reflect.ValueOf(cfg))
+	if err != nil {
+		return nil, err
+	}
+	doc := &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: "fundsTask 参考配置（由 `fundsTask config reference` 生成，值为默认值）\n支持 .json / .yaml / .yml / .toml，字段名在各格式中相同",
+		Content:     []*yaml.Node{root},
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode reference config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// referenceNode 将字段值转换为 YAML 节点，结构体字段的 doc 标签作为注释
+func referenceNode(v reflect.Value) (*yaml.Node, error) {
+	switch v.Kind() {
+	case reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := jsonFieldName(t.Field(i))
+			if name == "" {
+				continue
+			}
+			value, err := referenceNode(v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			key := &yaml.Node{
+				Kind:        yaml.ScalarNode,
+				Value:       name,
+				HeadComment: t.Field(i).Tag.Get("doc"),
+			}
+			node.Content = append(node.Content, key, value)
+		}
+		return node, nil
+
+	case reflect.Slice:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		if v.Type().Elem().Kind() != reflect.Struct {
+			node.Style = yaml.FlowStyle
+		}
+		for i := 0; i < v.Len(); i++ {
+			item, err := referenceNode(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, item)
+		}
+		return node, nil
+
+	default:
+		node := &yaml.Node{}
+		if err := node.Encode(v.Interface()); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+)
+
+// DefaultWatchInterval 轮询配置文件修改时间的间隔
+const DefaultWatchInterval = 10 * time.Second
+
+// reloadableFields 可在运行时热更新的顶层配置字段（json 名称），其余字段变化需要重启
+var reloadableFields = []string{"logLevel", "timer", "schedule", "coins"}
+
+var (
+	reloadMu    sync.Mutex
+	reloadHooks []func(old, cur *Config)
+	changed     = make(chan struct{})
+)
+
+// OnReload 注册热更新成功后的回调（如调整日志级别）
+func OnReload(hook func(old, cur *Config)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadHooks = append(reloadHooks, hook)
+}
+
+// Changed 返回在下一次热更新成功时关闭的通道，用于中断按旧配置进行的等待
+func Changed() <-chan struct{} {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	return changed
+}
+
+// Watch 轮询配置文件（含覆盖文件）的修改时间并响应 SIGHUP，发生变化时重新加载配置，ctx 取消时返回
+func Watch(ctx context.Context, opts LoadOptions, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	path, overlays := opts.resolvePaths()
+	files := append([]string{path}, overlays...)
+	lastState := fileState(files)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logger.Log.Info("Received SIGHUP, reloading config", nil)
+		case <-ticker.C:
+			state := fileState(files)
+			if state == lastState {
+				continue
+			}
+			lastState = state
+			logger.Log.Info("Config file changed, reloading config", map[string]interface{}{"files": files})
+		}
+
+		if err := Reload(opts); err != nil {
+			logger.Log.Error("Config reload failed, keeping current config", map[string]interface{}{"error": err.Error()})
+		}
+	}
+}
+
+// fileState 返回各文件修改时间与大小的摘要，文件不存在时记为 missing
+func fileState(files []string) string {
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			fmt.Fprintf(&b, "%s:missing;", file)
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", file, info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String()
+}
+
+// Reload 重新加载并校验配置，只应用可热更新的字段；需要重启的字段变化会被忽略并记录日志
+func Reload(opts LoadOptions) error {
+	next, err := Load(opts)
+	if err != nil {
+		return err
+	}
+
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	// 与文件配置比较，运行时设置不计入变化
+	if fileCfg == nil {
+		fileCfg = next
+		current.Store(next)
+		return nil
+	}
+	applied, rejected := diffConfig(fileCfg, next)
+
+	if len(rejected) > 0 {
+		logger.Log.Warn("Config changes require a restart and were not applied", map[string]interface{}{
+			"fields": rejected,
+		})
+	}
+	if len(applied) == 0 {
+		logger.Log.Info("No hot-reloadable config changes", nil)
+		return nil
+	}
+
+	// 在当前文件配置的副本上替换可热更新的字段
+	merged := *fileCfg
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	nextValue := reflect.ValueOf(next).Elem()
+	for i := 0; i < mergedValue.NumField(); i++ {
+		if contains(reloadableFields, jsonFieldName(mergedValue.Type().Field(i))) {
+			mergedValue.Field(i).Set(nextValue.Field(i))
+		}
+	}
+	fileCfg = &merged
+
+	logger.Log.Info("Config reloaded", map[string]interface{}{"fields": applied})
+
+	// 重新叠加运行时设置，设置与新配置冲突时只使用文件配置
+	effective, err := withOverrides(fileCfg, runtimeOverrides)
+	if err != nil {
+		logger.Log.Error("Runtime settings no longer valid, ignoring them", map[string]interface{}{"error": err.Error()})
+		effective = fileCfg
+	}
+	publish(effective)
+
+	return nil
+}
+
+// publish 替换当前生效的配置，有变化时执行回调并唤醒等待中的定时器，调用方需持有 reloadMu
+func publish(next *Config) {
+	old := current.Swap(next)
+	if old == nil {
+		return
+	}
+	if applied, rejected := diffConfig(old, next); len(applied) == 0 && len(rejected) == 0 {
+		return
+	}
+
+	for _, hook := range reloadHooks {
+		hook(old, next)
+	}
+	close(changed)
+	changed = make(chan struct{})
+}
+
+// diffConfig 比较两份配置的叶子字段，按是否可热更新分别返回变化的字段路径
+func diffConfig(old, next *Config) (applied, rejected []string) {
+	before := flattenConfig(old)
+	after := flattenConfig(next)
+
+	paths := make(map[string]bool)
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+
+	for path := range paths {
+		beforeValue, beforeOK := before[path]
+		afterValue, afterOK := after[path]
+		if beforeOK == afterOK && beforeValue == afterValue {
+			continue
+		}
+		if contains(reloadableFields, strings.SplitN(path, ".", 2)[0]) {
+			applied = append(applied, path)
+		} else {
+			rejected = append(rejected, path)
+		}
+	}
+
+	sort.Strings(applied)
+	sort.Strings(rejected)
+	return applied, rejected
+}
+
+// flattenConfig 将配置展开为 "字段路径 -> 值" 的映射
+func flattenConfig(cfg *Config) map[string]string {
+	values := make(map[string]string)
+	walkFields(reflect.ValueOf(cfg), nil, func(path []string, field reflect.Value) error {
+		values[strings.Join(path, ".")] = fmt.Sprint(field.Interface())
+		return nil
+	})
+	return values
+}
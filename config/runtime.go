@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	// fileCfg 从文件与环境变量加载的配置，运行时设置叠加在其上得到当前配置
+	fileCfg *Config
+	// runtimeOverrides 当前生效的运行时设置（字段路径 -> 值）
+	runtimeOverrides map[string]string
+)
+
+// IsRuntimeSetting 判断字段路径能否作为运行时设置（只允许可热更新的字段）
+func IsRuntimeSetting(key string) bool {
+	return contains(reloadableFields, strings.SplitN(key, ".", 2)[0]) && hasField(key)
+}
+
+// CheckRuntimeSetting 校验运行时设置：字段可热更新、值可解析且应用后配置仍然有效
+func CheckRuntimeSetting(key, value string) error {
+	if !IsRuntimeSetting(key) {
+		return fmt.Errorf("%s is not a runtime setting", key)
+	}
+
+	candidate := *Current()
+
+	if err := setField(&candidate, key, value); err != nil {
+		return err
+	}
+	return candidate.Validate()
+}
+
+// SetRuntimeOverrides 替换当前的运行时设置并重新计算当前配置，设置无效时保留原配置
+func SetRuntimeOverrides(overrides map[string]string) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if fileCfg == nil {
+		return fmt.Errorf("config not initialized")
+	}
+
+	next, err := withOverrides(fileCfg, overrides)
+	if err != nil {
+		return err
+	}
+	runtimeOverrides = overrides
+	publish(next)
+	return nil
+}
+
+// withOverrides 在 base 的副本上应用运行时设置
+func withOverrides(base *Config, overrides map[string]string) (*Config, error) {
+	cfg := *base
+	if len(overrides) == 0 {
+		return &cfg, nil
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !IsRuntimeSetting(key) {
+			return nil, fmt.Errorf("%s is not a runtime setting", key)
+		}
+		if err := setField(&cfg, key, overrides[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid runtime settings:\n%w", err)
+	}
+	return &cfg, nil
+}
+
+// setField 按点分隔的 json 字段路径（如 schedule.tradeInflowIntervalMinutes）设置字段值
+func setField(cfg *Config, key, value string) error {
+	found := false
+	err := walkFields(reflect.ValueOf(cfg), nil, func(path []string, field reflect.Value) error {
+		if strings.Join(path, ".") != key {
+			return nil
+		}
+		found = true
+		if err := setFieldFromString(field, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("unknown config field %s", key)
+	}
+	return nil
+}
+
+// hasField 判断字段路径是否存在
+func hasField(key string) bool {
+	found := false
+	walkFields(reflect.ValueOf(&Config{}), nil, func(path []string, field reflect.Value) error {
+		if strings.Join(path, ".") == key {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// SecretKeyFileEnv 指定密钥文件路径的环境变量，优先于配置中的 secretKeyFile
+	SecretKeyFileEnv = "FUNDSTASK_SECRET_KEY_FILE"
+
+	secretPrefixEnv   = "env:"   // env:NAME 读取环境变量
+	secretPrefixFile  = "file:"  // file:/run/secrets/name 读取文件内容（如 Docker/Kubernetes secret 挂载）
+	secretPrefixEnc   = "enc:"   // enc:<base64> 使用本地密钥文件解密（AES-256-GCM）
+	secretPrefixPlain = "plain:" // plain:xxx 按原样使用，用于本身以上述前缀开头的明文
+)
+
+// resolveSecrets 解析配置中的敏感字段引用
+func resolveSecrets(cfg *Config) error {
+	resolver := &secretResolver{keyFile: cfg.SecretKeyFile}
+	if path := os.Getenv(SecretKeyFileEnv); path != "" {
+		resolver.keyFile = path
+	}
+
+	fields := map[string]*string{
+		"database.password": &cfg.Database.Password,
+		"auth.apiKey":       &cfg.Auth.APIKey,
+	}
+	for i := range cfg.Login {
+		fields[fmt.Sprintf("login[%d].code", i)] = &cfg.Login[i].Code
+		fields[fmt.Sprintf("login[%d].imap.password", i)] = &cfg.Login[i].IMAP.Password
+	}
+
+	for name, field := range fields {
+		value, err := resolver.resolve(*field)
+		if err != nil {
+			return fmt.Errorf("failed to resolve secret %s: %w", name, err)
+		}
+		*field = value
+	}
+
+	return nil
+}
+
+// secretResolver 解析单个敏感值，按需加载密钥
+type secretResolver struct {
+	keyFile string
+	key     []byte
+}
+
+// resolve 根据前缀解析敏感值，没有前缀时按明文处理
+func (r *secretResolver) resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretPrefixEnv):
+		name := strings.TrimPrefix(value, secretPrefixEnv)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(value, secretPrefixFile):
+		data, err := os.ReadFile(strings.TrimPrefix(value, secretPrefixFile))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+
+	case strings.HasPrefix(value, secretPrefixEnc):
+		if err := r.loadKey(); err != nil {
+			return "", err
+		}
+		return DecryptSecret(r.key, strings.TrimPrefix(value, secretPrefixEnc))
+
+	case strings.HasPrefix(value, secretPrefixPlain):
+		return strings.TrimPrefix(value, secretPrefixPlain), nil
+
+	default:
+		return value, nil
+	}
+}
+
+// loadKey 加载密钥文件（只加载一次）
+func (r *secretResolver) loadKey() error {
+	if r.key != nil {
+		return nil
+	}
+	if r.keyFile == "" {
+		return fmt.Errorf("encrypted secret found but no key file configured (set secretKeyFile or %s)", SecretKeyFileEnv)
+	}
+
+	key, err := LoadSecretKey(r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.key = key
+	return nil
+}
+
+// LoadSecretKey 读取 base64 编码的 32 字节密钥文件
+func LoadSecretKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret key file: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secret key must be 32 bytes, got %d", len(key))
+	}
+
+	return key, nil
+}
+
+// GenerateSecretKey 生成 base64 编码的随机 32 字节密钥
+func GenerateSecretKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate secret key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptSecret 使用 AES-256-GCM 加密明文，返回可直接写入配置的 enc: 引用
+func EncryptSecret(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretPrefixEnc + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 生成的密文（不含 enc: 前缀）
+func DecryptSecret(key []byte, ciphertext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted secret: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted secret is too short")
+	}
+
+	nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// newGCM 创建 AES-GCM 加解密器
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/valuescan"
+)
+
+const (
+	ModeDev  = "dev"
+	ModeProd = "prod"
+)
+
+// 配置默认值，Validate 会为未填写的字段补上
+const (
+	DefaultMode                    = ModeProd
+	DefaultDatabasePort            = 5432
+	DefaultSSLMode                 = "disable"
+	DefaultDBApplicationName       = "fundsTask"
+	DefaultDBTimeZone              = "Asia/Shanghai"
+	DefaultDBConnectTimeoutSeconds = 10
+	DefaultDBMaxOpenConns          = 10
+	DefaultDBMaxIdleConns          = 5
+	DefaultDBConnMaxLifetime       = 1800 // 秒
+	DefaultDBConnMaxIdleTime       = 300  // 秒
+	DefaultDBStartupTimeoutSeconds = 120
+	DefaultAuthMode                = "login"
+	DefaultRefreshMarginSeconds    = 60
+	DefaultTokenStorePath          = "config/tokens.json"
+	DefaultThrottleCooldownSeconds = 300
+	DefaultDisableCooldownSeconds  = 3600
+	DefaultIMAPMailbox             = "INBOX"
+	DefaultIMAPPollSeconds         = 5
+	DefaultIMAPTimeoutSeconds      = 300
+	DefaultCoinInfoInterval        = 240 // 分钟
+	DefaultTradeInflowInterval     = 5   // 分钟
+	DefaultCoinPageSize            = 100
+
+	// ValueScan 接口地址、重试与熔断的默认值以 valuescan 包为准，这里只补充请求头
+	DefaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36"
+)
+
+var (
+	validModes        = []string{ModeDev, ModeProd}
+	validLogLevels    = []string{"", "debug", "info", "warn", "error"}
+	validAuthModes    = []string{"login", "apiKey", "none"}
+	validTrafficModes = []string{"", "record", "replay"}
+	validTokenStores  = []string{"", "file", "db"}
+	validSSLModes     = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+)
+
+// Validate 补全默认值并校验配置，返回所有问题合并后的错误
+func (c *Config) Validate() error {
+	c.applyDefaults()
+
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(contains(validModes, c.Mode), "mode: must be one of %v, got %q", validModes, c.Mode)
+	check(contains(validLogLevels, c.LogLevel), "logLevel: must be one of %v, got %q", validLogLevels, c.LogLevel)
+
+	// ValueScan 接口
+	if u, err := url.Parse(c.ValueScan.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("valuescan.baseURL: must be an absolute http(s) URL, got %q", c.ValueScan.BaseURL))
+	}
+	for _, p := range []struct {
+		name     string
+		path     string
+		optional bool
+	}{
+		{"login", c.ValueScan.Paths.Login, false},
+		{"refresh", c.ValueScan.Paths.Refresh, true},
+		{"sendCode", c.ValueScan.Paths.SendCode, true},
+		{"queryCoin", c.ValueScan.Paths.QueryCoin, false},
+		{"tradeInflow", c.ValueScan.Paths.TradeInflow, false},
+	} {
+		check((p.optional && p.path == "") || strings.HasPrefix(p.path, "/"), "valuescan.paths.%s: must start with /, got %q", p.name, p.path)
+	}
+	check(c.ValueScan.Retry.MaxAttempts > 0, "valuescan.retry.maxAttempts: must be positive")
+	check(c.ValueScan.Retry.BaseDelaySeconds > 0, "valuescan.retry.baseDelaySeconds: must be positive")
+	check(c.ValueScan.Retry.MaxDelaySeconds >= c.ValueScan.Retry.BaseDelaySeconds, "valuescan.retry.maxDelaySeconds: must not be less than baseDelaySeconds, got %d", c.ValueScan.Retry.MaxDelaySeconds)
+	check(c.ValueScan.Retry.BudgetSeconds >= 0, "valuescan.retry.budgetSeconds: must not be negative")
+	check(c.ValueScan.Breaker.FailureThreshold > 0, "valuescan.circuitBreaker.failureThreshold: must be positive")
+	check(c.ValueScan.Breaker.CooldownSeconds > 0, "valuescan.circuitBreaker.cooldownSeconds: must be positive")
+	for _, l := range []struct {
+		name  string
+		limit LimitConfig
+	}{
+		{"login", c.ValueScan.RateLimit.Login},
+		{"refresh", c.ValueScan.RateLimit.Refresh},
+		{"sendCode", c.ValueScan.RateLimit.SendCode},
+		{"queryCoin", c.ValueScan.RateLimit.QueryCoin},
+		{"tradeInflow", c.ValueScan.RateLimit.TradeInflow},
+		{"account", c.ValueScan.RateLimit.Account},
+	} {
+		check(l.limit.RequestsPerSecond >= 0, "valuescan.rateLimit.%s.requestsPerSecond: must not be negative", l.name)
+		check(l.limit.Burst >= 0, "valuescan.rateLimit.%s.burst: must not be negative", l.name)
+	}
+	check(contains(validTrafficModes, c.ValueScan.Traffic.Mode), "valuescan.traffic.mode: must be one of %v, got %q", validTrafficModes, c.ValueScan.Traffic.Mode)
+	check(c.ValueScan.Traffic.Mode == "" || c.ValueScan.Traffic.Dir != "", "valuescan.traffic.dir: required when traffic.mode is set")
+	// 回放的登录响应不含真实令牌，避免写入令牌存储覆盖可用的令牌
+	check(c.ValueScan.Traffic.Mode != "replay" || c.Auth.Mode == "none", "valuescan.traffic.mode: replay requires auth.mode none")
+	check(!c.ValueScan.Faults.Enabled || c.Mode != ModeProd, "valuescan.faults.enabled: not allowed in %s mode", ModeProd)
+	for _, f := range []struct {
+		name  string
+		fault FaultConfig
+	}{
+		{"login", c.ValueScan.Faults.Login},
+		{"refresh", c.ValueScan.Faults.Refresh},
+		{"sendCode", c.ValueScan.Faults.SendCode},
+		{"queryCoin", c.ValueScan.Faults.QueryCoin},
+		{"tradeInflow", c.ValueScan.Faults.TradeInflow},
+	} {
+		check(f.fault.LatencyMs >= 0 && f.fault.LatencyJitterMs >= 0, "valuescan.faults.%s: latency must not be negative", f.name)
+		check(f.fault.RetryAfterSeconds >= 0, "valuescan.faults.%s.retryAfterSeconds: must not be negative", f.name)
+		check(f.fault.Status == 0 || (f.fault.Status >= 100 && f.fault.Status < 600), "valuescan.faults.%s.status: must be a valid HTTP status, got %d", f.name, f.fault.Status)
+		for _, r := range []struct {
+			name string
+			rate float64
+		}{
+			{"errorRate", f.fault.ErrorRate},
+			{"statusRate", f.fault.StatusRate},
+			{"truncateRate", f.fault.TruncateRate},
+			{"malformedRate", f.fault.MalformedRate},
+			{"emptyDataRate", f.fault.EmptyDataRate},
+		} {
+			check(r.rate >= 0 && r.rate <= 1, "valuescan.faults.%s.%s: must be between 0 and 1, got %v", f.name, r.name, r.rate)
+		}
+	}
+
+	// 认证
+	check(contains(validAuthModes, c.Auth.Mode), "auth.mode: must be one of %v, got %q", validAuthModes, c.Auth.Mode)
+	check(c.Auth.Mode != "apiKey" || c.Auth.APIKey != "", "auth.apiKey: required when auth.mode is apiKey")
+	check(c.Auth.RefreshMarginSeconds >= 0, "auth.refreshMarginSeconds: must not be negative")
+	check(contains(validTokenStores, c.Auth.TokenStore.Type), "auth.tokenStore.type: must be one of %v, got %q", validTokenStores, c.Auth.TokenStore.Type)
+
+	// 登录账号：没有令牌存储时只能依赖登录码
+	if c.Auth.Mode == "login" {
+		check(len(c.Login) > 0, "login: at least one account is required when auth.mode is login")
+		for i, account := range c.Login {
+			check(account.PhoneOrEmail != "", "login[%d].phoneOrEmail: required", i)
+			check(account.Code != "" || c.Auth.TokenStore.Type != "", "login[%d].code: required unless auth.tokenStore is configured", i)
+			if account.IMAP.Addr != "" {
+				check(account.IMAP.Username != "", "login[%d].imap.username: required when imap.addr is set", i)
+				check(account.IMAP.PollIntervalSeconds > 0, "login[%d].imap.pollIntervalSeconds: must be positive", i)
+				check(account.IMAP.TimeoutSeconds > 0, "login[%d].imap.timeoutSeconds: must be positive", i)
+			}
+		}
+	}
+
+	check(c.Pool.ThrottleCooldownSeconds > 0, "pool.throttleCooldownSeconds: must be positive")
+	check(c.Pool.DisableCooldownSeconds > 0, "pool.disableCooldownSeconds: must be positive")
+
+	// 定时任务与币种过滤
+	check(c.Schedule.CoinInfoIntervalMinutes > 0 && c.Schedule.CoinInfoIntervalMinutes <= 1440, "schedule.coinInfoIntervalMinutes: must be between 1 and 1440, got %d", c.Schedule.CoinInfoIntervalMinutes)
+	check(c.Schedule.TradeInflowIntervalMinutes > 0 && c.Schedule.TradeInflowIntervalMinutes <= 1440, "schedule.tradeInflowIntervalMinutes: must be between 1 and 1440, got %d", c.Schedule.TradeInflowIntervalMinutes)
+	check(c.Coins.PageSize > 0, "coins.pageSize: must be positive")
+
+	// 数据库
+	check(c.Database.Host != "", "database.host: required")
+	check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port: must be between 1 and 65535, got %d", c.Database.Port)
+	check(c.Database.User != "", "database.user: required")
+	check(c.Database.DBName != "", "database.dbName: required")
+	check(contains(validSSLModes, c.Database.SSLMode), "database.sslMode: must be one of %v, got %q", validSSLModes, c.Database.SSLMode)
+	check(c.Database.SSLRootCert == "" || c.Database.SSLMode == "verify-ca" || c.Database.SSLMode == "verify-full", "database.sslRootCert: only used when sslMode is verify-ca or verify-full")
+	check(c.Database.ConnectTimeoutSeconds > 0, "database.connectTimeoutSeconds: must be positive")
+	check(c.Database.StatementTimeoutSeconds >= 0, "database.statementTimeoutSeconds: must not be negative")
+	check(c.Database.MaxOpenConns > 0, "database.maxOpenConns: must be positive")
+	check(c.Database.MaxIdleConns > 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "database.maxIdleConns: must be between 1 and maxOpenConns, got %d", c.Database.MaxIdleConns)
+	check(c.Database.ConnMaxLifetimeSeconds > 0, "database.connMaxLifetimeSeconds: must be positive")
+	check(c.Database.ConnMaxIdleTimeSeconds > 0, "database.connMaxIdleTimeSeconds: must be positive")
+	check(c.Database.StartupTimeoutSeconds > 0, "database.startupTimeoutSeconds: must be positive")
+
+	return errors.Join(errs...)
+}
+
+// applyDefaults 为未填写的字段补上默认值
+func (c *Config) applyDefaults() {
+	if c.Mode == "" {
+		c.Mode = DefaultMode
+	}
+
+	if c.ValueScan.BaseURL == "" {
+		c.ValueScan.BaseURL = valuescan.DefaultBaseURL
+	}
+	endpoints := valuescan.DefaultEndpoints()
+	setDefault(&c.ValueScan.Paths.Login, endpoints.Login)
+	setDefault(&c.ValueScan.Paths.Refresh, endpoints.Refresh)
+	setDefault(&c.ValueScan.Paths.SendCode, endpoints.SendCode)
+	setDefault(&c.ValueScan.Paths.QueryCoin, endpoints.QueryCoin)
+	setDefault(&c.ValueScan.Paths.TradeInflow, endpoints.TradeInflow)
+	if c.ValueScan.Headers == nil {
+		c.ValueScan.Headers = map[string]string{"User-Agent": DefaultUserAgent}
+	}
+	retry := valuescan.DefaultRetryPolicy()
+	setDefaultInt(&c.ValueScan.Retry.MaxAttempts, retry.MaxAttempts)
+	setDefaultInt(&c.ValueScan.Retry.BaseDelaySeconds, int(retry.BaseDelay/time.Second))
+	setDefaultInt(&c.ValueScan.Retry.MaxDelaySeconds, int(retry.MaxDelay/time.Second))
+	setDefaultInt(&c.ValueScan.Retry.BudgetSeconds, int(retry.Budget/time.Second))
+	if c.ValueScan.Retry.RetryableCodes == nil {
+		c.ValueScan.Retry.RetryableCodes = retry.RetryableCodes
+	}
+	breaker := valuescan.DefaultBreakerPolicy()
+	setDefaultInt(&c.ValueScan.Breaker.FailureThreshold, breaker.FailureThreshold)
+	setDefaultInt(&c.ValueScan.Breaker.CooldownSeconds, int(breaker.Cooldown/time.Second))
+
+	if c.Auth.Mode == "" {
+		c.Auth.Mode = DefaultAuthMode
+	}
+	if c.Auth.RefreshMarginSeconds == 0 {
+		c.Auth.RefreshMarginSeconds = DefaultRefreshMarginSeconds
+	}
+	if c.Auth.TokenStore.Type == "file" && c.Auth.TokenStore.Path == "" {
+		c.Auth.TokenStore.Path = DefaultTokenStorePath
+	}
+	// 业务码默认只有与 HTTP 状态码同名的 401/403 与 429，ValueScan 实际使用的业务码需由运维配置
+	errorCodes := valuescan.DefaultErrorCodes()
+	if len(c.Auth.TokenInvalidCodes) == 0 {
+		c.Auth.TokenInvalidCodes = errorCodes.Unauthorized
+	}
+	if len(c.Auth.ThrottleCodes) == 0 {
+		c.Auth.ThrottleCodes = errorCodes.RateLimited
+	}
+
+	for i := range c.Login {
+		imap := &c.Login[i].IMAP
+		if imap.Addr == "" {
+			continue
+		}
+		if imap.Mailbox == "" {
+			imap.Mailbox = DefaultIMAPMailbox
+		}
+		if imap.PollIntervalSeconds == 0 {
+			imap.PollIntervalSeconds = DefaultIMAPPollSeconds
+		}
+		if imap.TimeoutSeconds == 0 {
+			imap.TimeoutSeconds = DefaultIMAPTimeoutSeconds
+		}
+	}
+
+	if c.Pool.ThrottleCooldownSeconds == 0 {
+		c.Pool.ThrottleCooldownSeconds = DefaultThrottleCooldownSeconds
+	}
+	if c.Pool.DisableCooldownSeconds == 0 {
+		c.Pool.DisableCooldownSeconds = DefaultDisableCooldownSeconds
+	}
+
+	if c.Schedule.CoinInfoIntervalMinutes == 0 {
+		c.Schedule.CoinInfoIntervalMinutes = DefaultCoinInfoInterval
+	}
+	if c.Schedule.TradeInflowIntervalMinutes == 0 {
+		c.Schedule.TradeInflowIntervalMinutes = DefaultTradeInflowInterval
+	}
+	if c.Coins.PageSize == 0 {
+		c.Coins.PageSize = DefaultCoinPageSize
+	}
+
+	if c.Database.Port == 0 {
+		c.Database.Port = DefaultDatabasePort
+	}
+	if c.Database.SSLMode == "" {
+		c.Database.SSLMode = DefaultSSLMode
+	}
+	setDefault(&c.Database.ApplicationName, DefaultDBApplicationName)
+	setDefault(&c.Database.TimeZone, DefaultDBTimeZone)
+	setDefaultInt(&c.Database.ConnectTimeoutSeconds, DefaultDBConnectTimeoutSeconds)
+	setDefaultInt(&c.Database.MaxOpenConns, DefaultDBMaxOpenConns)
+	setDefaultInt(&c.Database.MaxIdleConns, min(DefaultDBMaxIdleConns, c.Database.MaxOpenConns))
+	setDefaultInt(&c.Database.ConnMaxLifetimeSeconds, DefaultDBConnMaxLifetime)
+	setDefaultInt(&c.Database.ConnMaxIdleTimeSeconds, DefaultDBConnMaxIdleTime)
+	setDefaultInt(&c.Database.StartupTimeoutSeconds, DefaultDBStartupTimeoutSeconds)
+}
+
+// setDefault 字符串字段为空时设置默认值
+func setDefault(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// setDefaultInt 整数字段为 0 时设置默认值
+func setDefaultInt(field *int, value int) {
+	if *field == 0 {
+		*field = value
+	}
+}
+
+// contains 判断字符串是否在列表中
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
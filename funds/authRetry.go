@@ -0,0 +1,45 @@
+package funds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cryptoSelect/fundsTask/auth"
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+)
+
+// doAuthorized 获取凭证执行请求；凭证被服务端拒绝时续期一次并透明重试
+// 凭证被限流或续期失败时由认证器决定是否换一份凭证重试
+// 续期成功后仍被拒绝按续期失败处理：停用该账号（冷却后恢复）并换下一个账号
+func doAuthorized(ctx context.Context, authenticator auth.Authenticator, call func(cred auth.Credential) error) error {
+	for {
+		cred, err := authenticator.Acquire()
+		if err != nil {
+			return err
+		}
+
+		err = call(cred)
+		if errors.Is(err, auth.ErrTokenRejected) {
+			logger.Log.Warn("Token rejected by server, renewing and retrying", map[string]interface{}{"error": err})
+
+			if err = cred.Renew(ctx); err == nil {
+				err = call(cred)
+				// 新令牌同样被拒绝，说明账号本身不可用（如被封禁），不再对该账号续期
+				if errors.Is(err, auth.ErrTokenRejected) {
+					err = fmt.Errorf("%w: token rejected again after renewal: %w", auth.ErrRenewFailed, err)
+				}
+			}
+		}
+
+		if !cred.Release(err) {
+			return err
+		}
+	}
+}
+
+// isAuthUnavailable 判断错误是否表示账号池中已没有可用账号，此时继续请求也必然失败
+// 单个账号续期失败只会停用该账号，不影响本轮其它请求
+func isAuthUnavailable(err error) bool {
+	return errors.Is(err, auth.ErrNoAvailableAccount)
+}
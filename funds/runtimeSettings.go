@@ -0,0 +1,17 @@
+package funds
+
+import (
+	"context"
+
+	"github.com/cryptoSelect/fundsTask/settings"
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+)
+
+// refreshSettings 在任务开始前读取运行时设置，失败时沿用当前配置继续执行
+func refreshSettings(ctx context.Context) {
+	if err := settings.Refresh(ctx); err != nil {
+		logger.Log.Warn("Failed to refresh runtime settings, using current config", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
@@ -1,124 +1,119 @@
 package funds
 
 import (
-	"encoding/json"
+	"context"
 	"strconv"
+	"time"
 
 	"github.com/cryptoSelect/fundsTask/auth"
+	"github.com/cryptoSelect/fundsTask/config"
 	"github.com/cryptoSelect/fundsTask/utils"
 	"github.com/cryptoSelect/fundsTask/utils/logger"
+	"github.com/cryptoSelect/fundsTask/valuescan"
 
 	"github.com/cryptoSelect/public/database"
 	publicModels "github.com/cryptoSelect/public/models"
 )
 
-func StartTask(_ *auth.TokenPair) {
-	logger.Log.Info("Starting coin info task with 4-hour interval", nil)
+// StartTask 运行币种信息定时任务，直到 ctx 取消
+func StartTask(ctx context.Context, authenticator auth.Authenticator, client *valuescan.Client) {
+	logger.Log.Info("Starting coin info task", map[string]interface{}{
+		"interval": coinInfoInterval().String(),
+	})
 
 	// 创建币种服务
-	coinService := NewCoinService()
+	coinService := NewCoinService(client)
+
+	// 运行定时器
+	runCoinInfoTimer(ctx, coinService, authenticator)
 
-	// 启动定时器
-	go runCoinInfoTimer(coinService)
+	logger.Log.Info("Coin info task stopped", nil)
 }
 
 // runCoinInfoTimer 运行币种信息定时器
-func runCoinInfoTimer(service *CoinService) {
-	for {
+func runCoinInfoTimer(ctx context.Context, service *CoinService, authenticator auth.Authenticator) {
+	for ctx.Err() == nil {
 		// 根据配置模式决定是否延时
 		if utils.ShouldDelay() {
-			utils.WaitForNext4HourMark()
+			if err := utils.WaitForNextInterval(ctx, "coin_info", coinInfoInterval); err != nil {
+				return
+			}
 		}
 
-		// 执行任务
-		processCoinInfoTask(service)
+		// 执行任务，单次执行不超过一个间隔
+		runCtx, cancel := context.WithTimeout(ctx, coinInfoInterval())
+		processCoinInfoTask(runCtx, service, authenticator)
+		cancel()
 	}
 }
 
+// coinInfoInterval 返回当前配置的币种信息任务间隔
+func coinInfoInterval() time.Duration {
+	return time.Duration(config.Current().Schedule.CoinInfoIntervalMinutes) * time.Minute
+}
+
 // processCoinInfoTask 处理币种信息任务
-func processCoinInfoTask(service *CoinService) {
+func processCoinInfoTask(ctx context.Context, service *CoinService, authenticator auth.Authenticator) {
 	logger.Log.Info("Processing coin info task", nil)
 
-	// 创建认证服务并获取有效 Token
-	authService := auth.NewAuthService()
-	tokenPair, err := authService.GetTokens()
-	if err != nil {
-		logger.Log.Error("Failed to get tokens for coin info task", map[string]interface{}{"error": err})
-		return
-	}
-
-	// 查询币种信息
-	coinService := NewCoinService()
-	coinResp, err := coinService.QueryCoins(tokenPair.AccountToken)
-	if err != nil {
-		logger.Log.Error("Coin query failed", map[string]interface{}{"error": err})
-		return
-	}
+	// 读取数据库中的运行时设置（币种过滤、任务间隔等）
+	refreshSettings(ctx)
 
-	logger.Log.Info("Coin query successful", map[string]interface{}{
-		"code":   coinResp.Code,
-		"req_id": coinResp.ReqID,
+	// 查询币种信息（令牌被拒绝时自动续期并重试，限流时换账号）
+	var coinResp *valuescan.Response[valuescan.CoinPage]
+	err := doAuthorized(ctx, authenticator, func(cred auth.Credential) error {
+		var err error
+		coinResp, err = service.QueryCoins(ctx, cred)
+		return err
 	})
-
-	// 解析响应数据
-	if coinResp.Code != 200 {
-		logger.Log.Error("Invalid response code", map[string]interface{}{
-			"code": coinResp.Code,
-			"msg":  coinResp.Msg,
-		})
-		return
-	}
-
-	// 将 interface{} 转换为 CoinData
-	dataBytes, err := json.Marshal(coinResp.Data)
 	if err != nil {
-		logger.Log.Error("Failed to marshal response data", map[string]interface{}{"error": err})
-		return
-	}
-
-	var coinData map[string]interface{}
-	err = json.Unmarshal(dataBytes, &coinData)
-	if err != nil {
-		logger.Log.Error("Failed to unmarshal coin data", map[string]interface{}{"error": err})
+		logger.Log.Error("Coin query failed", map[string]interface{}{
+			"error":       err,
+			"error_class": valuescan.ErrorClass(err),
+		})
 		return
 	}
 
+	coins := coinResp.Data.List
 	logger.Log.Info("Parsed coin data", map[string]interface{}{
-		"total": coinData["total"],
-		"count": len(coinData["list"].([]interface{})),
+		"total": coinResp.Data.Total,
+		"count": len(coins),
 	})
 
 	// 保存到数据库
-	if err := saveCoinInfoToDB(coinData["list"].([]interface{})); err != nil {
+	if err := saveCoinInfoToDB(ctx, coins); err != nil {
 		logger.Log.Error("Failed to save coin info to database", map[string]interface{}{"error": err})
 		return
 	}
 
 	logger.Log.Info("Coin info task completed successfully", map[string]interface{}{
-		"count": len(coinData["list"].([]interface{})),
+		"count": len(coins),
 	})
 }
 
 // saveCoinInfoToDB 保存币种信息到数据库
-func saveCoinInfoToDB(coinList []interface{}) error {
-	for _, coin := range coinList {
-		coinMap := coin.(map[string]interface{})
+func saveCoinInfoToDB(ctx context.Context, coins []valuescan.Coin) error {
+	for _, coin := range coins {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// 解析 VSTokenID
-		vsTokenID, err := strconv.ParseInt(coinMap["vsTokenId"].(string), 10, 64)
+		vsTokenID, err := strconv.ParseInt(coin.VSTokenID, 10, 64)
 		if err != nil {
 			logger.Log.Error("Failed to parse VSTokenID", map[string]interface{}{
-				"vsTokenId": coinMap["vsTokenId"],
+				"vsTokenId": coin.VSTokenID,
 				"error":     err,
 			})
 			continue
 		}
 
 		// 解析 MarketCap
-		marketCap, err := strconv.ParseFloat(coinMap["marketCap"].(string), 64)
+		marketCap, err := strconv.ParseFloat(coin.MarketCap, 64)
 		if err != nil {
 			logger.Log.Error("Failed to parse MarketCap", map[string]interface{}{
-				"symbol":    coinMap["symbol"],
-				"marketCap": coinMap["marketCap"],
+				"symbol":    coin.Symbol,
+				"marketCap": coin.MarketCap,
 				"error":     err,
 			})
 			continue
@@ -127,23 +122,23 @@ func saveCoinInfoToDB(coinList []interface{}) error {
 		// 创建 VsCoinInfo 记录
 		vsCoinInfo := publicModels.VsCoinInfo{
 			VSTokenID: vsTokenID,
-			Name:      coinMap["name"].(string),
-			Symbol:    coinMap["symbol"].(string),
+			Name:      coin.Name,
+			Symbol:    coin.Symbol,
 			MarketCap: marketCap,
 		}
 
 		// 使用 Upsert 方式保存（如果存在则更新，不存在则创建）
-		result := database.DB.Where("vs_token_id = ?", vsTokenID).
+		result := database.DB.WithContext(ctx).Where("vs_token_id = ?", vsTokenID).
 			Assign(&publicModels.VsCoinInfo{
-				Name:      coinMap["name"].(string),
-				Symbol:    coinMap["symbol"].(string),
+				Name:      coin.Name,
+				Symbol:    coin.Symbol,
 				MarketCap: marketCap,
 			}).
 			FirstOrCreate(&vsCoinInfo)
 
 		if result.Error != nil {
 			logger.Log.Error("Failed to save coin record", map[string]interface{}{
-				"symbol": coinMap["symbol"],
+				"symbol": coin.Symbol,
 				"error":  result.Error,
 			})
 			continue
@@ -151,8 +146,8 @@ func saveCoinInfoToDB(coinList []interface{}) error {
 
 		logger.Log.Debug("Coin info saved", map[string]interface{}{
 			"vs_token_id": vsTokenID,
-			"symbol":      coinMap["symbol"],
-			"name":        coinMap["name"],
+			"symbol":      coin.Symbol,
+			"name":        coin.Name,
 			"market_cap":  marketCap,
 		})
 	}
@@ -1,157 +1,95 @@
 package funds
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/cryptoSelect/fundsTask/auth"
+	"github.com/cryptoSelect/fundsTask/config"
 	"github.com/cryptoSelect/fundsTask/utils"
 	"github.com/cryptoSelect/fundsTask/utils/logger"
+	"github.com/cryptoSelect/fundsTask/valuescan"
 
 	"github.com/cryptoSelect/public/database"
 	publicModels "github.com/cryptoSelect/public/models"
 )
 
-const (
-	TradeInflowURL = "https://api.valuescan.io/api/trade/getCoinTradeInflow"
-)
-
-// TradeInflowResponse 资金流向查询响应
-type TradeInflowResponse struct {
-	Code     int         `json:"code"`
-	Data     interface{} `json:"data"`
-	Msg      string      `json:"msg"`
-	ReqID    string      `json:"reqId"`
-	UserRole string      `json:"userRole"`
-}
-
-// TradeInflowData 资金流向数据结构
-type TradeInflowData struct {
-	Total  int               `json:"total"`
-	List   []TradeInflowInfo `json:"list"`
-	Extend string            `json:"extend"`
-}
-
-// TradeInflowInfo 资金流向信息
-type TradeInflowInfo struct {
-	Symbol                    string  `json:"symbol"`
-	TimeParticleEnum          int     `json:"timeParticleEnum"`
-	Time                      string  `json:"time"`
-	Stop                      bool    `json:"stop"`
-	StopTradeInflow           float64 `json:"stopTradeInflow"`
-	StopTradeAmount           float64 `json:"stopTradeAmount"`
-	StopTradeInflowChange     float64 `json:"stopTradeInflowChange"`
-	StopTradeAmountChange     float64 `json:"stopTradeAmountChange"`
-	Contract                  bool    `json:"contract"`
-	ContractTradeInflow       float64 `json:"contractTradeInflow"`
-	ContractTradeAmount       float64 `json:"contractTradeAmount"`
-	ContractTradeInflowChange float64 `json:"contractTradeInflowChange"`
-	ContractTradeAmountChange float64 `json:"contractTradeAmountChange"`
-	StopTradeIn               float64 `json:"stopTradeIn"`
-	StopTradeOut              float64 `json:"stopTradeOut"`
-	ContractTradeIn           float64 `json:"contractTradeIn"`
-	ContractTradeOut          float64 `json:"contractTradeOut"`
-}
-
 // TradeInflowService 资金流向服务
 type TradeInflowService struct {
-	client *http.Client
+	client *valuescan.Client
 }
 
 // NewTradeInflowService 创建资金流向服务
-func NewTradeInflowService() *TradeInflowService {
-	return &TradeInflowService{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
+func NewTradeInflowService(client *valuescan.Client) *TradeInflowService {
+	return &TradeInflowService{client: client}
 }
 
 // GetTradeInflow 获取资金流向数据
-func (s *TradeInflowService) GetTradeInflow(accessToken, vsTokenID string) (*TradeInflowResponse, error) {
-	// 验证 Token 有效性
-	authService := auth.NewAuthService()
-	validToken, err := authService.ValidateAndRefreshToken(accessToken)
-	if err != nil {
-		return nil, fmt.Errorf("failed to validate/refresh token: %w", err)
-	}
-
-	// 构建请求URL
-	url := fmt.Sprintf("%s?keyword=%s", TradeInflowURL, vsTokenID)
-
-	// 创建请求
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("accessToken", validToken)
-
-	// 发送请求
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
+func (s *TradeInflowService) GetTradeInflow(ctx context.Context, cred auth.Credential, vsTokenID string) (*valuescan.Response[valuescan.TradeInflow], error) {
+	resp, err := s.client.GetCoinTradeInflow(ctx, cred, vsTokenID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// 解析响应
-	var tradeInflowResp TradeInflowResponse
-	err = json.Unmarshal(body, &tradeInflowResp)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		// 令牌失效与限流映射为认证层错误，由调用方续期或换账号
+		return nil, auth.ClassifyError(err)
 	}
 
 	logger.Log.Info("Trade inflow response received", map[string]interface{}{
 		"vs_token_id": vsTokenID,
-		"code":        tradeInflowResp.Code,
-		"req_id":      tradeInflowResp.ReqID,
+		"code":        resp.Code,
+		"req_id":      resp.ReqID,
 	})
 
-	return &tradeInflowResp, nil
+	return resp, nil
 }
 
-// StartTradeInflowTask 启动资金流向定时任务
-func StartTradeInflowTask(tokenPair *auth.TokenPair) {
-	logger.Log.Info("Starting trade inflow task", nil)
+// StartTradeInflowTask 运行资金流向定时任务，直到 ctx 取消
+func StartTradeInflowTask(ctx context.Context, authenticator auth.Authenticator, client *valuescan.Client) {
+	logger.Log.Info("Starting trade inflow task", map[string]interface{}{
+		"interval": tradeInflowInterval().String(),
+	})
 
 	// 创建资金流向服务
-	tradeInflowService := NewTradeInflowService()
+	tradeInflowService := NewTradeInflowService(client)
+
+	// 运行定时器
+	runTradeInflowTimer(ctx, tradeInflowService, authenticator)
 
-	// 启动定时器
-	go runTradeInflowTimer(tradeInflowService, tokenPair.AccountToken)
+	logger.Log.Info("Trade inflow task stopped", nil)
 }
 
 // runTradeInflowTimer 运行资金流向定时器
-func runTradeInflowTimer(service *TradeInflowService, accessToken string) {
-	for {
+func runTradeInflowTimer(ctx context.Context, service *TradeInflowService, authenticator auth.Authenticator) {
+	for ctx.Err() == nil {
 		// 根据配置模式决定是否延时
 		if utils.ShouldDelay() {
-			utils.WaitForNext5MinuteMark()
+			if err := utils.WaitForNextInterval(ctx, "trade_inflow", tradeInflowInterval); err != nil {
+				return
+			}
 		}
 
-		// 执行任务
-		processTradeInflow(service, accessToken)
+		// 执行任务，单次执行不超过一个间隔，避免一轮扫描拖进下一轮
+		runCtx, cancel := context.WithTimeout(ctx, tradeInflowInterval())
+		processTradeInflow(runCtx, service, authenticator)
+		cancel()
 	}
 }
 
+// tradeInflowInterval 返回当前配置的资金流向任务间隔
+func tradeInflowInterval() time.Duration {
+	return time.Duration(config.Current().Schedule.TradeInflowIntervalMinutes) * time.Minute
+}
+
 // processTradeInflow 处理资金流向数据
-func processTradeInflow(service *TradeInflowService, accessToken string) {
+func processTradeInflow(ctx context.Context, service *TradeInflowService, authenticator auth.Authenticator) {
 	logger.Log.Info("Processing trade inflow data", nil)
 
+	// 读取数据库中的运行时设置（关注列表、任务间隔等）
+	refreshSettings(ctx)
+
 	// 查询数据库中所有的 VSTokenID
-	vsTokenIDs, err := getVSTokenIDsFromDB()
+	vsTokenIDs, err := getVSTokenIDsFromDB(ctx)
 	if err != nil {
 		logger.Log.Error("Failed to get VSTokenIDs from database", map[string]interface{}{"error": err})
 		return
@@ -163,13 +101,48 @@ func processTradeInflow(service *TradeInflowService, accessToken string) {
 
 	// 遍历每个 VSTokenID 查询资金流向
 	successCount := 0
-	currentToken := accessToken // 跟踪当前有效的 Token
 
-	for _, vsTokenID := range vsTokenIDs {
-		if err := queryAndSaveTradeInflow(service, &currentToken, vsTokenID); err != nil {
+	for i, vsTokenID := range vsTokenIDs {
+		// 进程退出或本轮超时时中止
+		if err := ctx.Err(); err != nil {
+			logger.Log.Warn("Trade inflow run cancelled", map[string]interface{}{
+				"success":   successCount,
+				"remaining": len(vsTokenIDs) - i,
+				"reason":    err.Error(),
+			})
+			return
+		}
+
+		// 令牌被拒绝时自动续期并重试一次，账号被限流时换账号
+		err := doAuthorized(ctx, authenticator, func(cred auth.Credential) error {
+			return queryAndSaveTradeInflow(ctx, service, cred, vsTokenID)
+		})
+
+		// 没有可用凭证时后续请求也必然失败，直接中止本轮
+		if isAuthUnavailable(err) {
+			logger.Log.Error("No usable credential, aborting trade inflow run", map[string]interface{}{
+				"success":   successCount,
+				"remaining": len(vsTokenIDs) - i,
+				"error":     err,
+			})
+			return
+		}
+
+		// 接口熔断说明上游故障，暂停本轮，等下个周期再扫描
+		if errors.Is(err, valuescan.ErrCircuitOpen) {
+			logger.Log.Warn("ValueScan trade inflow endpoint is unavailable, pausing trade inflow run", map[string]interface{}{
+				"success":   successCount,
+				"remaining": len(vsTokenIDs) - i,
+				"error":     err.Error(),
+			})
+			return
+		}
+
+		if err != nil {
 			logger.Log.Error("Failed to process trade inflow for token", map[string]interface{}{
 				"vs_token_id": vsTokenID,
 				"error":       err,
+				"error_class": valuescan.ErrorClass(err),
 			})
 			continue
 		}
@@ -183,14 +156,15 @@ func processTradeInflow(service *TradeInflowService, accessToken string) {
 	})
 }
 
-// getVSTokenIDsFromDB 从数据库获取所有 VSTokenID
-func getVSTokenIDsFromDB() ([]int64, error) {
+// getVSTokenIDsFromDB 从数据库获取需要扫描的 VSTokenID，配置了关注列表时只返回列表中的币种
+func getVSTokenIDsFromDB(ctx context.Context) ([]int64, error) {
 	var vsTokenIDs []int64
 
-	// 查询所有 VSTokenID
-	err := database.DB.Model(&publicModels.VsCoinInfo{}).
-		Pluck("vs_token_id", &vsTokenIDs).
-		Error
+	query := database.DB.WithContext(ctx).Model(&publicModels.VsCoinInfo{})
+	if watchlist := config.Current().Coins.Watchlist; len(watchlist) > 0 {
+		query = query.Where("symbol IN ?", watchlist)
+	}
+	err := query.Pluck("vs_token_id", &vsTokenIDs).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to query VSTokenIDs: %w", err)
@@ -200,98 +174,63 @@ func getVSTokenIDsFromDB() ([]int64, error) {
 }
 
 // queryAndSaveTradeInflow 查询并保存资金流向数据
-func queryAndSaveTradeInflow(service *TradeInflowService, accessToken *string, vsTokenID int64) error {
+func queryAndSaveTradeInflow(ctx context.Context, service *TradeInflowService, cred auth.Credential, vsTokenID int64) error {
 	// 转换 VSTokenID 为字符串
 	vsTokenIDStr := strconv.FormatInt(vsTokenID, 10)
 
-	// 查询资金流向数据（会自动验证和刷新 Token）
-	resp, err := service.GetTradeInflow(*accessToken, vsTokenIDStr)
+	// 查询资金流向数据
+	resp, err := service.GetTradeInflow(ctx, cred, vsTokenIDStr)
 	if err != nil {
 		return fmt.Errorf("failed to get trade inflow: %w", err)
 	}
 
-	// 检查响应状态
-	if resp.Code != 200 {
-		return fmt.Errorf("invalid response code: %d, msg: %s", resp.Code, resp.Msg)
-	}
-
-	// 解析数据
-	dataBytes, err := json.Marshal(resp.Data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal response data: %w", err)
-	}
-
-	var tradeData map[string]interface{}
-	err = json.Unmarshal(dataBytes, &tradeData)
-	if err != nil {
-		return fmt.Errorf("failed to unmarshal trade data: %w", err)
-	}
-
-	// 调试：打印完整的响应数据结构
-	logger.Log.Info("Trade inflow API response structure", map[string]interface{}{
-		"vs_token_id":   vsTokenID,
-		"response_data": tradeData,
-	})
-
-	// 检查数据结构
-	if tradeData["coinTradeInflowDtoList"] == nil {
+	tradeInflow := resp.Data
+	if len(tradeInflow.List) == 0 {
 		logger.Log.Info("No trade inflow data found", map[string]interface{}{
-			"vs_token_id":    vsTokenID,
-			"available_keys": getMapKeys(tradeData),
+			"vs_token_id": vsTokenID,
 		})
 		return nil
 	}
 
-	// 处理资金流向列表
-	tradeList := tradeData["coinTradeInflowDtoList"].([]interface{})
-	symbol := getString(tradeData["symbol"]) // 从外层获取 symbol
-
 	logger.Log.Info("Found trade inflow data", map[string]interface{}{
 		"vs_token_id": vsTokenID,
-		"symbol":      symbol,
-		"list_length": len(tradeList),
+		"symbol":      tradeInflow.Symbol,
+		"list_length": len(tradeInflow.List),
 	})
 
-	return saveTradeInflowToDB(tradeList, symbol)
-}
-
-// getMapKeys 获取 map 的所有键，用于调试
-func getMapKeys(m map[string]interface{}) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
+	return saveTradeInflowToDB(ctx, tradeInflow.List, tradeInflow.Symbol)
 }
 
 // saveTradeInflowToDB 保存资金流向数据到数据库
-func saveTradeInflowToDB(tradeList []interface{}, symbol string) error {
-	for _, trade := range tradeList {
-		tradeMap := trade.(map[string]interface{})
+func saveTradeInflowToDB(ctx context.Context, items []valuescan.TradeInflowItem, symbol string) error {
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		// 创建 CoinTradeInflowDto 记录
 		tradeInflow := publicModels.CoinTradeInflowDto{
 			Symbol:                    symbol, // 从外层获取 symbol
-			TimeParticleEnum:          getInt(tradeMap["timeParticleEnum"]),
-			Time:                      getString(tradeMap["time"]),
-			Stop:                      getBool(tradeMap["stop"]),
-			StopTradeInflow:           getFloat64(tradeMap["stopTradeInflow"]),
-			StopTradeAmount:           getFloat64(tradeMap["stopTradeAmount"]),
-			StopTradeInflowChange:     getFloat64(tradeMap["stopTradeInflowChange"]),
-			StopTradeAmountChange:     getFloat64(tradeMap["stopTradeAmountChange"]),
-			Contract:                  getBool(tradeMap["contract"]),
-			ContractTradeInflow:       getFloat64(tradeMap["contractTradeInflow"]),
-			ContractTradeAmount:       getFloat64(tradeMap["contractTradeAmount"]),
-			ContractTradeInflowChange: getFloat64(tradeMap["contractTradeInflowChange"]),
-			ContractTradeAmountChange: getFloat64(tradeMap["contractTradeAmountChange"]),
-			StopTradeIn:               getFloat64(tradeMap["stopTradeIn"]),
-			StopTradeOut:              getFloat64(tradeMap["stopTradeOut"]),
-			ContractTradeIn:           getFloat64(tradeMap["contractTradeIn"]),
-			ContractTradeOut:          getFloat64(tradeMap["contractTradeOut"]),
+			TimeParticleEnum:          int(item.TimeParticleEnum),
+			Time:                      string(item.Time),
+			Stop:                      bool(item.Stop),
+			StopTradeInflow:           float64(item.StopTradeInflow),
+			StopTradeAmount:           float64(item.StopTradeAmount),
+			StopTradeInflowChange:     float64(item.StopTradeInflowChange),
+			StopTradeAmountChange:     float64(item.StopTradeAmountChange),
+			Contract:                  bool(item.Contract),
+			ContractTradeInflow:       float64(item.ContractTradeInflow),
+			ContractTradeAmount:       float64(item.ContractTradeAmount),
+			ContractTradeInflowChange: float64(item.ContractTradeInflowChange),
+			ContractTradeAmountChange: float64(item.ContractTradeAmountChange),
+			StopTradeIn:               float64(item.StopTradeIn),
+			StopTradeOut:              float64(item.StopTradeOut),
+			ContractTradeIn:           float64(item.ContractTradeIn),
+			ContractTradeOut:          float64(item.ContractTradeOut),
 		}
 
 		// 保存到数据库（使用 Upsert 方式）
-		result := database.DB.Where("symbol = ? AND time = ?", tradeInflow.Symbol, tradeInflow.Time).
+		result := database.DB.WithContext(ctx).Where("symbol = ? AND time = ?", tradeInflow.Symbol, tradeInflow.Time).
 			Assign(&tradeInflow).
 			FirstOrCreate(&tradeInflow)
 
@@ -312,65 +251,3 @@ func saveTradeInflowToDB(tradeList []interface{}, symbol string) error {
 
 	return nil
 }
-
-// 辅助函数：安全地从 interface{} 获取各种类型的数据
-func getString(value interface{}) string {
-	if value == nil {
-		return ""
-	}
-	if str, ok := value.(string); ok {
-		return str
-	}
-	return fmt.Sprintf("%v", value)
-}
-
-func getInt(value interface{}) int {
-	if value == nil {
-		return 0
-	}
-	if num, ok := value.(float64); ok {
-		return int(num)
-	}
-	if num, ok := value.(int); ok {
-		return num
-	}
-	if str, ok := value.(string); ok {
-		if i, err := strconv.Atoi(str); err == nil {
-			return i
-		}
-	}
-	return 0
-}
-
-func getFloat64(value interface{}) float64 {
-	if value == nil {
-		return 0
-	}
-	if num, ok := value.(float64); ok {
-		return num
-	}
-	if num, ok := value.(int); ok {
-		return float64(num)
-	}
-	if str, ok := value.(string); ok {
-		if f, err := strconv.ParseFloat(str, 64); err == nil {
-			return f
-		}
-	}
-	return 0
-}
-
-func getBool(value interface{}) bool {
-	if value == nil {
-		return false
-	}
-	if b, ok := value.(bool); ok {
-		return b
-	}
-	if str, ok := value.(string); ok {
-		if b, err := strconv.ParseBool(str); err == nil {
-			return b
-		}
-	}
-	return false
-}
@@ -4,7 +4,11 @@ go 1.25.4
 
 require (
 	github.com/0xA2618/logjson v1.0.0
+	github.com/BurntSushi/toml v1.5.0
 	github.com/cryptoSelect/public v1.0.3
+	gopkg.in/yaml.v3 v3.0.1
+	gorm.io/driver/postgres v1.6.0
+	gorm.io/gorm v1.31.1
 )
 
 require (
@@ -17,6 +21,4 @@ require (
 	golang.org/x/crypto v0.31.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
-	gorm.io/driver/postgres v1.6.0 // indirect
-	gorm.io/gorm v1.31.1 // indirect
 )
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/auth"
+	"github.com/cryptoSelect/fundsTask/config"
+	"github.com/cryptoSelect/fundsTask/settings"
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+)
+
+// commandNeedsConfig 判断子命令是否需要先加载配置文件
+func commandNeedsConfig(name string) bool {
+	return name != "secret" && name != "config"
+}
+
+// runCommandAndExit 执行子命令，失败时以非零状态退出
+func runCommandAndExit(ctx context.Context, name string, args []string) {
+	if err := runCommand(ctx, name, args); err != nil {
+		logger.Log.Error("Command failed", map[string]interface{}{
+			"command": name,
+			"error":   err.Error(),
+		})
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// runCommand 执行子命令
+func runCommand(ctx context.Context, name string, args []string) error {
+	switch name {
+	case "login":
+		return runLogin(ctx, args)
+	case "secret":
+		return runSecret(args)
+	case "audit":
+		return runAudit(ctx, args)
+	case "config":
+		return runConfig(args)
+	case "settings":
+		return runSettings(ctx, args)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+// runLogin 请求验证码、读取验证码并完成登录，会话写入令牌存储
+// 用法: fundsTask login [-account <phoneOrEmail>] [-source stdin|imap]
+func runLogin(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	accountFlag := fs.String("account", "", "account to log in (defaults to the first configured account)")
+	sourceFlag := fs.String("source", "", "where to read the login code from: stdin or imap (defaults to imap when configured)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	account, err := findAccount(*accountFlag)
+	if err != nil {
+		return err
+	}
+
+	source := *sourceFlag
+	if source == "" {
+		source = "stdin"
+		if account.IMAP.Addr != "" {
+			source = "imap"
+		}
+	}
+
+	var codeSource auth.CodeSource
+	switch source {
+	case "stdin":
+		codeSource = auth.NewStdinCodeSource(os.Stdin, os.Stderr)
+	case "imap":
+		codeSource, err = auth.NewIMAPCodeSource(account.IMAP)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown code source %q", source)
+	}
+
+	// 数据库令牌存储需要先连接数据库
+	if config.Current().Auth.TokenStore.Type == auth.TokenStoreDB {
+		if err := initDatabase(ctx); err != nil {
+			return err
+		}
+	}
+
+	client, err := newValueScanClient()
+	if err != nil {
+		return err
+	}
+	tokenPair, err := auth.NewAccountAuthService(client, account).Bootstrap(ctx, codeSource)
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Info("Login bootstrap completed", map[string]interface{}{
+		"account":            auth.MaskAccount(account.PhoneOrEmail),
+		"source":             source,
+		"expires_at":         tokenPair.ExpiresAt,
+		"refresh_expires_at": tokenPair.RefreshExpiresAt,
+	})
+	return nil
+}
+
+// findAccount 按账号查找登录配置，为空时返回第一个账号
+func findAccount(phoneOrEmail string) (config.LoginConfig, error) {
+	accounts := config.Current().Login
+	if phoneOrEmail == "" {
+		if len(accounts) == 0 {
+			return config.LoginConfig{}, fmt.Errorf("no login account configured")
+		}
+		return accounts.Primary(), nil
+	}
+
+	for _, account := range accounts {
+		if account.PhoneOrEmail == phoneOrEmail {
+			return account, nil
+		}
+	}
+	return config.LoginConfig{}, fmt.Errorf("account %q is not configured", phoneOrEmail)
+}
+
+// runSecret 生成密钥或加密敏感值（明文从标准输入读取）
+// 用法: fundsTask secret keygen | fundsTask secret encrypt -key <keyFile>
+func runSecret(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: secret keygen | secret encrypt -key <keyFile>")
+	}
+
+	switch args[0] {
+	case "keygen":
+		key, err := config.GenerateSecretKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+
+	case "encrypt":
+		fs := flag.NewFlagSet("secret encrypt", flag.ContinueOnError)
+		keyFile := fs.String("key", os.Getenv(config.SecretKeyFileEnv), "path to the base64 encoded 32-byte key file")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		key, err := config.LoadSecretKey(*keyFile)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(os.Stderr, "Enter the value to encrypt: ")
+		plaintext, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && plaintext == "" {
+			return fmt.Errorf("failed to read value: %w", err)
+		}
+
+		encrypted, err := config.EncryptSecret(key, strings.TrimRight(plaintext, "\r\n"))
+		if err != nil {
+			return err
+		}
+		fmt.Println(encrypted)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown secret command %q", args[0])
+	}
+}
+
+// runAudit 查询认证审计记录
+// 用法: fundsTask audit [-account <phoneOrEmail>] [-outcome success|failure] [-since 24h] [-limit 50]
+func runAudit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	account := fs.String("account", "", "only show events for this account")
+	outcome := fs.String("outcome", "", "only show events with this outcome: success or failure")
+	since := fs.Duration("since", 0, "only show events newer than this duration, e.g. 24h")
+	limit := fs.Int("limit", 50, "maximum number of events to show")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := initDatabase(ctx); err != nil {
+		return err
+	}
+
+	filter := auth.AuthEventFilter{
+		Account: *account,
+		Outcome: *outcome,
+		Limit:   *limit,
+	}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+
+	events, err := auth.QueryAuthEvents(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACCOUNT\tACTION\tOUTCOME\tCODE\tREQ_ID\tUSER_ROLE\tREASON")
+	for _, e := range events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			e.CreatedAt.Format(time.RFC3339), e.Account, e.Action, e.Outcome, e.Code, e.ReqID, e.UserRole, e.Reason)
+	}
+	return w.Flush()
+}
+
+// runConfig 配置相关命令
+// 用法: fundsTask [--config <file>] config check | fundsTask config reference
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: config check | config reference")
+	}
+
+	switch args[0] {
+	case "check":
+		if _, err := config.Load(loadOptions); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return fmt.Errorf("config check failed")
+		}
+		fmt.Println("config OK")
+		return nil
+
+	case "reference":
+		reference, err := config.Reference()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(reference)
+		return err
+
+	default:
+		return fmt.Errorf("unknown config command %q", args[0])
+	}
+}
+
+// runSettings 管理数据库中的运行时设置，修改记录保存修改人与时间
+// 用法: fundsTask settings list | set [-by <name>] <key> <value> | unset [-by <name>] <key> | history [-key <key>] [-limit 50]
+func runSettings(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: settings list | set [-by <name>] <key> <value> | unset [-by <name>] <key> | history [-key <key>] [-limit 50]")
+	}
+
+	if err := initDatabase(ctx); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		rows, err := settings.List(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tUPDATED_BY\tUPDATED_AT")
+		for _, row := range rows {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row.Key, row.Value, row.UpdatedBy, row.UpdatedAt.Format(time.RFC3339))
+		}
+		return w.Flush()
+
+	case "set", "unset":
+		fs := flag.NewFlagSet("settings "+args[0], flag.ContinueOnError)
+		by := fs.String("by", os.Getenv("USER"), "who is making the change")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *by == "" {
+			return fmt.Errorf("-by is required")
+		}
+
+		if args[0] == "set" {
+			if fs.NArg() != 2 {
+				return fmt.Errorf("usage: settings set [-by <name>] <key> <value>")
+			}
+			return settings.Set(ctx, fs.Arg(0), fs.Arg(1), *by)
+		}
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: settings unset [-by <name>] <key>")
+		}
+		return settings.Unset(ctx, fs.Arg(0), *by)
+
+	case "history":
+		fs := flag.NewFlagSet("settings history", flag.ContinueOnError)
+		key := fs.String("key", "", "only show changes to this setting")
+		limit := fs.Int("limit", 50, "maximum number of changes to show")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		changes, err := settings.History(ctx, *key, *limit)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tKEY\tOLD_VALUE\tNEW_VALUE\tCHANGED_BY")
+		for _, c := range changes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				c.CreatedAt.Format(time.RFC3339), c.Key, displayValue(c.OldValue), displayValue(c.NewValue), c.ChangedBy)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown settings command %q", args[0])
+	}
+}
+
+// displayValue 格式化可能为空的设置值
+func displayValue(value *string) string {
+	if value == nil {
+		return "-"
+	}
+	return *value
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+	"github.com/cryptoSelect/fundsTask/valuescan"
+)
+
+// healthResponse 健康检查输出
+// status 为 degraded 表示有接口处于熔断状态（上游故障），进程本身仍在正常运行
+type healthResponse struct {
+	Status    string                             `json:"status"`
+	Time      time.Time                          `json:"time"`
+	ValueScan map[string]valuescan.BreakerStatus `json:"valuescan"`
+}
+
+// startHealthServer 启动健康检查 HTTP 接口（GET /health），ctx 取消时关闭
+func startHealthServer(ctx context.Context, addr string, client *valuescan.Client) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		resp := healthResponse{
+			Status:    "ok",
+			Time:      time.Now(),
+			ValueScan: client.BreakerStatuses(),
+		}
+		for _, status := range resp.ValueScan {
+			if status.State != valuescan.BreakerClosed {
+				resp.Status = "degraded"
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	logger.Log.Info("Health server listening", map[string]interface{}{"addr": addr})
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Log.Error("Health server stopped", map[string]interface{}{"error": err.Error()})
+	}
+}
@@ -1,73 +1,238 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
 	"github.com/cryptoSelect/fundsTask/auth"
 	"github.com/cryptoSelect/fundsTask/config"
 	"github.com/cryptoSelect/fundsTask/funds"
+	"github.com/cryptoSelect/fundsTask/settings"
+	"github.com/cryptoSelect/fundsTask/utils/db"
 	"github.com/cryptoSelect/fundsTask/utils/logger"
+	"github.com/cryptoSelect/fundsTask/valuescan"
 	"github.com/cryptoSelect/public/database"
 	publicModels "github.com/cryptoSelect/public/models"
 )
 
+// loadOptions 命令行指定的配置加载选项
+var loadOptions config.LoadOptions
+
 func main() {
+	// 全局参数写在子命令之前，如 fundsTask --config prod.json login
+	configPath := flag.String("config", "", "config file path (defaults to $"+config.ConfigFileEnv+" or "+config.DefaultConfigFile+")")
+	configOverlay := flag.String("config-overlay", "", "comma separated overlay files merged on top of the config file (defaults to $"+config.ConfigOverlayEnv+")")
+	flag.Parse()
+	args := flag.Args()
+
+	loadOptions = config.LoadOptions{Path: *configPath}
+	if *configOverlay != "" {
+		loadOptions.Overlays = strings.Split(*configOverlay, ",")
+	}
+
+	// 收到 SIGINT / SIGTERM 时取消 ctx，进行中的请求、数据库操作与等待随之停止
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 不依赖已加载配置的子命令（如 secret、config check）先执行
+	if len(args) > 0 && !commandNeedsConfig(args[0]) {
+		logger.Init("")
+		runCommandAndExit(ctx, args[0], args[1:])
+	}
+
 	// 初始化配置
-	config.Init()
+	if err := config.Init(loadOptions); err != nil {
+		logger.Init("")
+		logger.Log.Error("Failed to load config", map[string]interface{}{"error": err.Error()})
+		os.Exit(1)
+	}
 
 	// 初始化日志
-	logger.Init(config.Cfg.Mode)
+	cfg := config.Current()
+	logger.Configure(cfg.Mode, cfg.LogLevel)
 
-	// 初始化数据库
-	database.InitDB(
-		config.Cfg.Database.Host,
-		config.Cfg.Database.User,
-		config.Cfg.Database.Password,
-		config.Cfg.Database.DBName,
-		config.Cfg.Database.Port,
-	)
+	// 子命令（如 login）执行完即退出
+	if len(args) > 0 {
+		runCommandAndExit(ctx, args[0], args[1:])
+	}
 
-	// 自动迁移数据库表
-	err := database.AutoMigrate(
-		&publicModels.CoinTradeInflowDto{},
-		&publicModels.VsCoinInfo{},
-	)
-	if err != nil {
-		logger.Log.Error("Database migration failed", map[string]interface{}{"error": err})
+	// 初始化数据库
+	if err := initDatabase(ctx); err != nil {
+		logger.Log.Error("Database initialization failed", map[string]interface{}{"error": err.Error()})
 		return
 	}
 
-	logger.Log.Info("Database migration completed successfully")
-
 	logger.Log.Info("Application starting", map[string]interface{}{
-		"mode": config.Cfg.Mode,
+		"mode": cfg.Mode,
 		"app":  "FundsTask",
 	})
 
-	// 创建认证服务
-	authService := auth.NewAuthService()
-
-	// 执行登录
-	logger.Log.Info("Starting login process")
-	tokenPair, err := authService.GetTokens()
+	// 创建认证器：登录模式下为每个账号加载缓存的会话，必要时续期或重新登录
+	// 两个任务共享同一个认证器，任一处续期对全进程生效
+	logger.Log.Info("Starting login process", map[string]interface{}{
+		"auth_mode": cfg.Auth.Mode,
+		"accounts":  len(cfg.Login),
+	})
+	client, err := newValueScanClient()
+	if err != nil {
+		logger.Log.Error("Failed to create ValueScan client", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	authenticator, err := auth.NewAuthenticator(ctx, client)
 	if err != nil {
 		logger.Log.Error("Login failed", map[string]interface{}{"error": err})
 		return
 	}
 
-	// 输出登录结果
-	logger.Log.Info("Login successful", map[string]interface{}{
-		"token_valid":       tokenPair.IsValid(),
-		"refresh_valid":     tokenPair.IsRefreshValid(),
-		"account_token_len": len(tokenPair.AccountToken),
-		"refresh_token_len": len(tokenPair.RefreshToken),
+	// 健康检查接口输出各接口熔断状态，便于区分上游故障与程序问题
+	if cfg.Health.Addr != "" {
+		go startHealthServer(ctx, cfg.Health.Addr, client)
+	}
+
+	// 监听配置文件变化与 SIGHUP，热更新定时间隔、币种过滤与日志级别
+	config.OnReload(func(old, cur *config.Config) {
+		if old.LogLevel != cur.LogLevel {
+			logger.Configure(cur.Mode, cur.LogLevel)
+		}
 	})
+	go config.Watch(ctx, loadOptions, config.DefaultWatchInterval)
+
+	var tasks sync.WaitGroup
 
 	// 启动币种信息定时任务
-	go funds.StartTask(nil)
+	tasks.Go(func() { funds.StartTask(ctx, authenticator, client) })
 
 	// 启动资金流向定时任务
-	go funds.StartTradeInflowTask(tokenPair)
+	tasks.Go(func() { funds.StartTradeInflowTask(ctx, authenticator, client) })
+
+	// 收到退出信号后等待进行中的任务停止
+	<-ctx.Done()
+	logger.Log.Info("Shutdown signal received, waiting for running tasks", nil)
+	tasks.Wait()
+	logger.Log.Info("Shutdown complete", nil)
+}
+
+// newValueScanClient 按配置创建 ValueScan 客户端，认证与各任务共享同一个客户端
+func newValueScanClient() (*valuescan.Client, error) {
+	cfg := config.Current()
+	vs := cfg.ValueScan
+	opts := []valuescan.Option{
+		valuescan.WithEndpoints(valuescan.Endpoints(vs.Paths)),
+		valuescan.WithHeaders(vs.Headers),
+		valuescan.WithLogger(logger.Log),
+		valuescan.WithRetryPolicy(valuescan.RetryPolicy{
+			MaxAttempts:    vs.Retry.MaxAttempts,
+			BaseDelay:      time.Duration(vs.Retry.BaseDelaySeconds) * time.Second,
+			MaxDelay:       time.Duration(vs.Retry.MaxDelaySeconds) * time.Second,
+			Budget:         time.Duration(vs.Retry.BudgetSeconds) * time.Second,
+			RetryableCodes: vs.Retry.RetryableCodes,
+		}),
+		valuescan.WithBreakerPolicy(valuescan.BreakerPolicy{
+			FailureThreshold: vs.Breaker.FailureThreshold,
+			Cooldown:         time.Duration(vs.Breaker.CooldownSeconds) * time.Second,
+		}),
+		valuescan.WithErrorCodes(valuescan.ErrorCodes{
+			Unauthorized: cfg.Auth.TokenInvalidCodes,
+			RateLimited:  cfg.Auth.ThrottleCodes,
+		}),
+		valuescan.WithRateLimits(valuescan.RateLimits{
+			Login:       limitOf(vs.RateLimit.Login),
+			Refresh:     limitOf(vs.RateLimit.Refresh),
+			SendCode:    limitOf(vs.RateLimit.SendCode),
+			QueryCoin:   limitOf(vs.RateLimit.QueryCoin),
+			TradeInflow: limitOf(vs.RateLimit.TradeInflow),
+			Account:     limitOf(vs.RateLimit.Account),
+		}),
+	}
+
+	// 录制或回放上游请求
+	var transport http.RoundTripper
+	switch vs.Traffic.Mode {
+	case "record":
+		recorder, err := valuescan.NewRecordingTransport(vs.Traffic.Dir, nil)
+		if err != nil {
+			return nil, err
+		}
+		recorder.Logger = logger.Log
+		transport = recorder
+		logger.Log.Warn("Recording ValueScan traffic", map[string]interface{}{"dir": vs.Traffic.Dir})
+	case "replay":
+		replay, err := valuescan.NewReplayTransport(vs.Traffic.Dir)
+		if err != nil {
+			return nil, err
+		}
+		transport = replay
+		logger.Log.Warn("Replaying recorded ValueScan traffic, network is not used", map[string]interface{}{"dir": vs.Traffic.Dir})
+	}
 
-	// 保持主程序运行
-	select {}
+	// 故障注入叠加在录制与回放之上，可以对回放的真实数据注入故障
+	if vs.Faults.Enabled {
+		faults := valuescan.NewFaultTransport(transport, valuescan.Endpoints(vs.Paths), valuescan.Faults{
+			Login:       faultOf(vs.Faults.Login),
+			Refresh:     faultOf(vs.Faults.Refresh),
+			SendCode:    faultOf(vs.Faults.SendCode),
+			QueryCoin:   faultOf(vs.Faults.QueryCoin),
+			TradeInflow: faultOf(vs.Faults.TradeInflow),
+		})
+		faults.Logger = logger.Log
+		transport = faults
+		logger.Log.Warn("ValueScan fault injection enabled", map[string]interface{}{"mode": cfg.Mode})
+	}
+
+	if transport != nil {
+		opts = append(opts, valuescan.WithTransport(transport))
+	}
 
+	return valuescan.NewClient(vs.BaseURL, opts...), nil
+}
+
+// faultOf 将故障注入配置转换为注入规则
+func faultOf(cfg config.FaultConfig) valuescan.Fault {
+	return valuescan.Fault{
+		Latency:       time.Duration(cfg.LatencyMs) * time.Millisecond,
+		LatencyJitter: time.Duration(cfg.LatencyJitterMs) * time.Millisecond,
+		ErrorRate:     cfg.ErrorRate,
+		StatusRate:    cfg.StatusRate,
+		Status:        cfg.Status,
+		RetryAfter:    time.Duration(cfg.RetryAfterSeconds) * time.Second,
+		TruncateRate:  cfg.TruncateRate,
+		MalformedRate: cfg.MalformedRate,
+		EmptyDataRate: cfg.EmptyDataRate,
+	}
+}
+
+// limitOf 将限速配置转换为令牌桶参数
+func limitOf(cfg config.LimitConfig) valuescan.Limit {
+	return valuescan.Limit{Rate: cfg.RequestsPerSecond, Burst: cfg.Burst}
+}
+
+// initDatabase 初始化数据库并自动迁移表结构
+func initDatabase(ctx context.Context) error {
+	// 按配置连接数据库，启动时数据库尚未就绪会按退避重试
+	if err := db.Connect(ctx, config.Current().Database); err != nil {
+		return err
+	}
+
+	// 自动迁移数据库表
+	err := database.DB.WithContext(ctx).AutoMigrate(
+		&publicModels.CoinTradeInflowDto{},
+		&publicModels.VsCoinInfo{},
+		&auth.TokenRecord{},
+		&auth.AuthEvent{},
+		&settings.Setting{},
+		&settings.SettingChange{},
+	)
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Info("Database migration completed successfully")
+	return nil
 }
@@ -0,0 +1,163 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/config"
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+
+	"github.com/cryptoSelect/public/database"
+	"gorm.io/gorm"
+)
+
+// Setting 运行时设置，覆盖配置文件中同名字段（如 schedule.tradeInflowIntervalMinutes）
+type Setting struct {
+	Key       string    `gorm:"primaryKey;comment:配置字段路径" json:"key"`
+	Value     string    `gorm:"type:text;comment:设置值（切片用逗号分隔）" json:"value"`
+	UpdatedBy string    `gorm:"comment:最后修改人" json:"updatedBy"`
+	UpdatedAt time.Time `gorm:"comment:最后修改时间" json:"updatedAt"`
+}
+
+func (Setting) TableName() string {
+	return "runtime_setting"
+}
+
+// SettingChange 运行时设置修改记录
+type SettingChange struct {
+	ID        uint      `gorm:"primaryKey;comment:主键ID" json:"id"`
+	CreatedAt time.Time `gorm:"index;comment:修改时间" json:"createdAt"`
+	Key       string    `gorm:"index;comment:配置字段路径" json:"key"`
+	OldValue  *string   `gorm:"type:text;comment:修改前的值（为空表示新增）" json:"oldValue"`
+	NewValue  *string   `gorm:"type:text;comment:修改后的值（为空表示删除）" json:"newValue"`
+	ChangedBy string    `gorm:"comment:修改人" json:"changedBy"`
+}
+
+func (SettingChange) TableName() string {
+	return "runtime_setting_change"
+}
+
+// Refresh 从数据库读取运行时设置并叠加到当前配置，每次任务执行前调用
+func Refresh(ctx context.Context) error {
+	if database.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	var rows []Setting
+	if err := database.DB.WithContext(ctx).Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to load runtime settings: %w", err)
+	}
+
+	overrides := make(map[string]string, len(rows))
+	for _, row := range rows {
+		overrides[row.Key] = row.Value
+	}
+
+	if err := config.SetRuntimeOverrides(overrides); err != nil {
+		return err
+	}
+
+	logger.Log.Debug("Runtime settings applied", map[string]interface{}{"count": len(overrides)})
+	return nil
+}
+
+// Set 新增或修改运行时设置，并记录修改人与时间
+func Set(ctx context.Context, key, value, changedBy string) error {
+	if err := config.CheckRuntimeSetting(key, value); err != nil {
+		return err
+	}
+
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		oldValue, err := currentValue(tx, key)
+		if err != nil {
+			return err
+		}
+
+		setting := Setting{Key: key}
+		result := tx.Where("key = ?", key).
+			Assign(map[string]interface{}{"value": value, "updated_by": changedBy}).
+			FirstOrCreate(&setting)
+		if result.Error != nil {
+			return fmt.Errorf("failed to save setting %s: %w", key, result.Error)
+		}
+
+		return recordChange(tx, key, oldValue, &value, changedBy)
+	})
+}
+
+// Unset 删除运行时设置，字段恢复为配置文件中的值
+func Unset(ctx context.Context, key, changedBy string) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		oldValue, err := currentValue(tx, key)
+		if err != nil {
+			return err
+		}
+		if oldValue == nil {
+			return fmt.Errorf("setting %s is not set", key)
+		}
+
+		if err := tx.Where("key = ?", key).Delete(&Setting{}).Error; err != nil {
+			return fmt.Errorf("failed to delete setting %s: %w", key, err)
+		}
+
+		return recordChange(tx, key, oldValue, nil, changedBy)
+	})
+}
+
+// List 返回所有运行时设置
+func List(ctx context.Context) ([]Setting, error) {
+	var rows []Setting
+	if err := database.DB.WithContext(ctx).Order("key").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	return rows, nil
+}
+
+// History 查询设置修改记录，按时间倒序，key 为空时返回全部
+func History(ctx context.Context, key string, limit int) ([]SettingChange, error) {
+	query := database.DB.WithContext(ctx).Order("created_at DESC")
+	if key != "" {
+		query = query.Where("key = ?", key)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var changes []SettingChange
+	if err := query.Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to query setting history: %w", err)
+	}
+	return changes, nil
+}
+
+// currentValue 读取设置当前的值，未设置时返回 nil
+func currentValue(tx *gorm.DB, key string) (*string, error) {
+	var rows []Setting
+	if err := tx.Where("key = ?", key).Limit(1).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read setting %s: %w", key, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0].Value, nil
+}
+
+// recordChange 写入一条修改记录
+func recordChange(tx *gorm.DB, key string, oldValue, newValue *string, changedBy string) error {
+	change := SettingChange{
+		Key:       key,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		ChangedBy: changedBy,
+	}
+	if err := tx.Create(&change).Error; err != nil {
+		return fmt.Errorf("failed to record setting change: %w", err)
+	}
+
+	logger.Log.Info("Runtime setting changed", map[string]interface{}{
+		"key":        key,
+		"changed_by": changedBy,
+	})
+	return nil
+}
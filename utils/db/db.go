@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/fundsTask/config"
+	"github.com/cryptoSelect/fundsTask/utils/logger"
+
+	"github.com/cryptoSelect/public/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+const (
+	initialRetryBackoff = time.Second
+	maxRetryBackoff     = 30 * time.Second
+)
+
+// Connect 按配置连接数据库，设置 database.DB；数据库暂不可用时按指数退避重试，直到 startupTimeoutSeconds 或 ctx 取消
+func Connect(ctx context.Context, cfg config.DatabaseConfig) error {
+	deadline := time.Now().Add(time.Duration(cfg.StartupTimeoutSeconds) * time.Second)
+	backoff := initialRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		db, err := open(ctx, cfg)
+		if err == nil {
+			database.DB = db
+			logger.Log.Info("Database connected", map[string]interface{}{
+				"host":     cfg.Host,
+				"db_name":  cfg.DBName,
+				"ssl_mode": cfg.SSLMode,
+				"attempts": attempt,
+			})
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("database connection cancelled: %w", ctx.Err())
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("database not available after %d attempts: %w", attempt, err)
+		}
+
+		logger.Log.Warn("Database not available, retrying", map[string]interface{}{
+			"attempt":       attempt,
+			"retry_seconds": backoff.Seconds(),
+			"error":         err.Error(),
+		})
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("database connection cancelled: %w", ctx.Err())
+		}
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// open 建立连接、配置连接池并确认数据库可用
+func open(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(DSN(cfg)), &gorm.Config{
+		Logger: gormLogger.Default.LogMode(gormLogger.Info),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second)
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTimeSeconds) * time.Second)
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// DSN 根据配置生成 PostgreSQL 连接串（key=value 形式）
+func DSN(cfg config.DatabaseConfig) string {
+	params := [][2]string{
+		{"host", cfg.Host},
+		{"port", fmt.Sprint(cfg.Port)},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"dbname", cfg.DBName},
+		{"sslmode", cfg.SSLMode},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"application_name", cfg.ApplicationName},
+		{"TimeZone", cfg.TimeZone},
+		{"connect_timeout", fmt.Sprint(cfg.ConnectTimeoutSeconds)},
+	}
+	if cfg.StatementTimeoutSeconds > 0 {
+		params = append(params, [2]string{"statement_timeout", fmt.Sprint(cfg.StatementTimeoutSeconds * 1000)})
+	}
+
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		if p[1] == "" {
+			continue
+		}
+		parts = append(parts, p[0]+"="+quoteDSNValue(p[1]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSNValue 按 libpq 规则为值加引号，转义其中的反斜杠与单引号
+func quoteDSNValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
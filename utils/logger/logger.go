@@ -2,25 +2,65 @@ package logger
 
 import (
 	"os"
+	"sync/atomic"
 
 	"github.com/0xA2618/logjson"
 )
 
-// Log is the global logger instance
-var Log *logjson.Logger
+// Logger wraps a logjson.Logger that can be swapped atomically at runtime,
+// so the level can change on config reload while other goroutines are logging
+type Logger struct {
+	current atomic.Pointer[logjson.Logger]
+}
+
+// Debug logs a message at Debug level.
+func (l *Logger) Debug(msg string, fields ...map[string]interface{}) {
+	l.current.Load().Debug(msg, fields...)
+}
+
+// Info logs a message at Info level.
+func (l *Logger) Info(msg string, fields ...map[string]interface{}) {
+	l.current.Load().Info(msg, fields...)
+}
+
+// Warn logs a message at Warn level.
+func (l *Logger) Warn(msg string, fields ...map[string]interface{}) {
+	l.current.Load().Warn(msg, fields...)
+}
+
+// Error logs a message at Error level.
+func (l *Logger) Error(msg string, fields ...map[string]interface{}) {
+	l.current.Load().Error(msg, fields...)
+}
+
+// Fatal logs a message at Fatal level and then exits.
+func (l *Logger) Fatal(msg string, fields ...map[string]interface{}) {
+	l.current.Load().Fatal(msg, fields...)
+}
+
+// Log is the global logger instance; it is never reassigned, Configure swaps its underlying logger
+var Log = &Logger{}
 
 // Init initializes the global logger with application context
 // mode: "dev" for debug level, otherwise info level
 func Init(mode string) {
-	// Determine log level from mode
-	level := logjson.LevelInfo
+	Configure(mode, "")
+}
+
+// Configure (re)creates the underlying logger, safe to call while other goroutines log
+// level: debug / info / warn / error; empty derives the level from mode
+func Configure(mode, level string) {
+	logLevel := logjson.LevelInfo
 	if mode == "dev" {
-		level = logjson.LevelDebug
+		logLevel = logjson.LevelDebug
+	}
+	if level != "" {
+		logLevel = logjson.ParseLevel(level)
 	}
 
-	Log = logjson.New(
+	Log.current.Store(logjson.New(
 		logjson.WithOutput(os.Stdout),
-		logjson.WithLevel(level),
+		logjson.WithLevel(logLevel),
 		logjson.WithField("app", "FundsTask"),
-	)
+	))
 }
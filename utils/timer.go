@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"time"
 
 	"github.com/cryptoSelect/fundsTask/config"
@@ -10,59 +11,46 @@ import (
 // ShouldDelay 判断是否需要延时
 func ShouldDelay() bool {
 	// 如果是生产环境，需要延时
-	return config.Cfg.Mode == "prod"
+	return config.Current().Mode == config.ModeProd
 }
 
-// WaitForNext5MinuteMark 等待下一个5分钟时间点
-func WaitForNext5MinuteMark() {
-	now := time.Now()
-
-	// 计算下一个5分钟时间点
-	nextMinute := ((now.Minute() / 5) + 1) * 5
-	if nextMinute >= 60 {
-		nextMinute = 0
-	}
-
-	// 计算到下一个5分钟时间点的时长
-	nextTime := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), nextMinute, 0, 0, now.Location())
-	if nextTime.Before(now) {
-		nextTime = nextTime.Add(1 * time.Hour)
+// WaitForNextInterval 等待下一个按间隔对齐的时间点（从当天 00:00 起算），ctx 取消时返回其错误
+// interval 每次重新读取，配置热更新后立即按新的间隔重新计算等待时间
+func WaitForNextInterval(ctx context.Context, task string, interval func() time.Duration) error {
+	for {
+		every := interval()
+		nextTime := nextIntervalMark(time.Now(), every)
+		delay := time.Until(nextTime)
+
+		logger.Log.Info("Waiting for next execution time", map[string]interface{}{
+			"task":          task,
+			"interval":      every.String(),
+			"next_time":     nextTime.Format("15:04:05"),
+			"delay_seconds": int(delay.Seconds()),
+			"mode":          config.Current().Mode,
+		})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+			return nil
+		case <-config.Changed():
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
 	}
-
-	delay := nextTime.Sub(now)
-
-	logger.Log.Info("Waiting for next execution time", map[string]interface{}{
-		"next_time":     nextTime.Format("15:04:05"),
-		"delay_seconds": int(delay.Seconds()),
-		"mode":          config.Cfg.Mode,
-	})
-
-	time.Sleep(delay)
 }
 
-// WaitForNext4HourMark 等待下一个4小时时间点
-func WaitForNext4HourMark() {
-	now := time.Now()
+// nextIntervalMark 计算 now 之后下一个按间隔对齐的时间点，不会跨过次日 00:00
+func nextIntervalMark(now time.Time, interval time.Duration) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	next := midnight.Add((now.Sub(midnight)/interval + 1) * interval)
 
-	// 计算下一个4小时时间点（00:00, 04:00, 08:00, 12:00, 16:00, 20:00）
-	nextHour := ((now.Hour() / 4) + 1) * 4
-	if nextHour >= 24 {
-		nextHour = 0
+	nextMidnight := midnight.AddDate(0, 0, 1)
+	if next.After(nextMidnight) {
+		next = nextMidnight
 	}
-
-	// 计算到下一个4小时时间点的时长
-	nextTime := time.Date(now.Year(), now.Month(), now.Day(), nextHour, 0, 0, 0, now.Location())
-	if nextTime.Before(now) {
-		nextTime = nextTime.Add(24 * time.Hour)
-	}
-
-	delay := nextTime.Sub(now)
-
-	logger.Log.Info("Waiting for next coin info execution time", map[string]interface{}{
-		"next_time":   nextTime.Format("15:04:05"),
-		"delay_hours": delay.Hours(),
-		"mode":        config.Cfg.Mode,
-	})
-
-	time.Sleep(delay)
+	return next
 }
@@ -0,0 +1,197 @@
+package valuescan
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 接口熔断中，请求未发送
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState 熔断器状态
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // 正常放行
+	BreakerOpen     BreakerState = "open"      // 快速失败，冷却结束后进入半开
+	BreakerHalfOpen BreakerState = "half-open" // 放行一个探测请求，成功则关闭，失败则重新打开
+)
+
+// BreakerPolicy 熔断策略
+// 同一接口连续 FailureThreshold 次上游故障（超时、连接错误、HTTP 5xx）后打开，
+// Cooldown 之后放行一个探测请求；FailureThreshold 为 0 表示不熔断
+type BreakerPolicy struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// DefaultBreakerPolicy 返回默认的熔断策略
+func DefaultBreakerPolicy() BreakerPolicy {
+	return BreakerPolicy{FailureThreshold: 5, Cooldown: time.Minute}
+}
+
+// WithBreakerPolicy 设置熔断策略
+func WithBreakerPolicy(policy BreakerPolicy) Option {
+	return func(c *Client) {
+		c.breakerPolicy = policy
+	}
+}
+
+// BreakerStatus 单个接口熔断器的状态快照
+type BreakerStatus struct {
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	OpenedAt            *time.Time   `json:"openedAt,omitempty"`
+	LastError           string       `json:"lastError,omitempty"`
+}
+
+// breaker 单个接口的熔断器
+type breaker struct {
+	endpoint string
+	policy   BreakerPolicy
+	log      Logger
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time // 最近一次打开的时间，用于计算冷却
+	downSince time.Time // 从关闭状态第一次打开的时间
+	probing   bool
+	lastError string
+}
+
+// allow 判断是否放行请求，打开状态冷却结束后转为半开并放行一个探测请求
+func (b *breaker) allow() error {
+	if b == nil || b.policy.FailureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		retryAt := b.openedAt.Add(b.policy.Cooldown)
+		if time.Now().Before(retryAt) {
+			return fmt.Errorf("%s: %w until %s", b.endpoint, ErrCircuitOpen, retryAt.Format(time.RFC3339))
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		b.log.Info("ValueScan circuit breaker half-open, probing", map[string]interface{}{
+			"endpoint": b.endpoint,
+		})
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%s: %w, probe in progress", b.endpoint, ErrCircuitOpen)
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record 记录一次请求的结果
+func (b *breaker) record(err error) {
+	if b == nil || b.policy.FailureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !isUpstreamFailure(err) {
+		if b.state != BreakerClosed {
+			b.log.Info("ValueScan circuit breaker closed", map[string]interface{}{
+				"endpoint": b.endpoint,
+				"down_for": time.Since(b.downSince).Round(time.Second).String(),
+			})
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+		b.lastError = ""
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	if b.state == BreakerHalfOpen || b.failures >= b.policy.FailureThreshold {
+		if b.state == BreakerClosed {
+			b.downSince = time.Now()
+		}
+		if b.state != BreakerOpen {
+			b.log.Warn("ValueScan circuit breaker opened", map[string]interface{}{
+				"endpoint":             b.endpoint,
+				"consecutive_failures": b.failures,
+				"cooldown":             b.policy.Cooldown.String(),
+				"error":                b.lastError,
+			})
+		}
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// abandon 请求被调用方取消，不计入结果，只释放探测名额
+func (b *breaker) abandon() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// status 返回状态快照
+func (b *breaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// isUpstreamFailure 判断错误是否表示上游不可用：HTTP 5xx、超时与连接错误
+// 服务端正常返回的 4xx 与业务码错误（包括 5xx 业务码）说明上游可用，不计入熔断
+func isUpstreamFailure(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus >= http.StatusInternalServerError
+	}
+	return errors.Is(err, ErrUpstream)
+}
+
+// newBreakers 为每个已配置的接口创建熔断器，未配置（路径为空）的接口不创建
+func newBreakers(endpoints Endpoints, policy BreakerPolicy, log Logger) map[string]*breaker {
+	breakers := make(map[string]*breaker)
+	for _, path := range []string{endpoints.Login, endpoints.Refresh, endpoints.SendCode, endpoints.QueryCoin, endpoints.TradeInflow} {
+		if path == "" {
+			continue
+		}
+		breakers[path] = &breaker{endpoint: path, policy: policy, log: log, state: BreakerClosed}
+	}
+	return breakers
+}
+
+// BreakerStatuses 返回各接口熔断器的状态，键为接口路径
+func (c *Client) BreakerStatuses() map[string]BreakerStatus {
+	statuses := make(map[string]BreakerStatus, len(c.breakers))
+	for path, b := range c.breakers {
+		statuses[path] = b.status()
+	}
+	return statuses
+}
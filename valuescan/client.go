@@ -0,0 +1,324 @@
+package valuescan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBaseURL ValueScan 接口根地址
+	DefaultBaseURL = "https://api.valuescan.io"
+
+	defaultTimeout = 30 * time.Second
+	// maxErrorBody 错误信息中保留的响应体长度
+	maxErrorBody = 512
+)
+
+// Endpoints 各接口路径，为空的接口不可用
+type Endpoints struct {
+	Login       string
+	Refresh     string // 刷新令牌与发送验证码接口没有公开的接口约定，没有默认值，需由使用方配置
+	SendCode    string
+	QueryCoin   string
+	TradeInflow string
+}
+
+// DefaultEndpoints 返回 ValueScan 的默认接口路径，Refresh 与 SendCode 为空
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		Login:       "/api/authority/login",
+		QueryCoin:   "/api/vs-token/queryCoin",
+		TradeInflow: "/api/trade/getCoinTradeInflow",
+	}
+}
+
+// Credential 为请求附加凭证（auth.Credential 满足该接口）
+type Credential interface {
+	Apply(req *http.Request) error
+}
+
+// Client ValueScan 接口客户端，可在多个任务间共享
+type Client struct {
+	baseURL    string
+	endpoints  Endpoints
+	headers    map[string]string
+	httpClient *http.Client
+	retry      RetryPolicy
+	limits     RateLimits
+	limiter    *rateLimiter
+
+	breakerPolicy BreakerPolicy
+	breakers      map[string]*breaker
+
+	errorCodes ErrorCodes
+	log        Logger
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithEndpoints 设置接口路径
+func WithEndpoints(endpoints Endpoints) Option {
+	return func(c *Client) {
+		c.endpoints = endpoints
+	}
+}
+
+// WithHeaders 设置附加到每个请求的请求头
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		c.headers = headers
+	}
+}
+
+// WithHTTPClient 使用自定义的 http.Client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient 创建客户端，baseURL 为空时使用 DefaultBaseURL
+func NewClient(baseURL string, opts ...Option) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		endpoints:  DefaultEndpoints(),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		retry:      DefaultRetryPolicy(),
+
+		breakerPolicy: DefaultBreakerPolicy(),
+		errorCodes:    DefaultErrorCodes(),
+		log:           nopLogger{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.limiter = newRateLimiter(c.endpoints, c.limits)
+	c.breakers = newBreakers(c.endpoints, c.breakerPolicy, c.log)
+	return c
+}
+
+// Endpoints 返回客户端使用的接口路径
+func (c *Client) Endpoints() Endpoints {
+	return c.endpoints
+}
+
+// Login 使用验证码登录
+func (c *Client) Login(ctx context.Context, req LoginRequest) (*Response[LoginData], error) {
+	return call[LoginData](ctx, c, nil, http.MethodPost, c.endpoints.Login, nil, req)
+}
+
+// Refresh 使用刷新令牌换取新的令牌
+func (c *Client) Refresh(ctx context.Context, req RefreshRequest) (*Response[LoginData], error) {
+	return call[LoginData](ctx, c, nil, http.MethodPost, c.endpoints.Refresh, nil, req)
+}
+
+// SendCode 请求向账号发送登录验证码
+func (c *Client) SendCode(ctx context.Context, req SendCodeRequest) (*Response[json.RawMessage], error) {
+	return call[json.RawMessage](ctx, c, nil, http.MethodPost, c.endpoints.SendCode, nil, req)
+}
+
+// QueryCoin 查询币种列表
+func (c *Client) QueryCoin(ctx context.Context, cred Credential, req QueryCoinRequest) (*Response[CoinPage], error) {
+	return call[CoinPage](ctx, c, cred, http.MethodPost, c.endpoints.QueryCoin, nil, req)
+}
+
+// GetCoinTradeInflow 查询币种的资金流向，keyword 为 vsTokenId
+func (c *Client) GetCoinTradeInflow(ctx context.Context, cred Credential, keyword string) (*Response[TradeInflow], error) {
+	return call[TradeInflow](ctx, c, cred, http.MethodGet, c.endpoints.TradeInflow, url.Values{"keyword": {keyword}}, nil)
+}
+
+// call 发送请求并解析响应，可重试的失败按 RetryPolicy 重试；ctx 取消时中止请求与等待
+// HTTP 状态码非 200 时返回 *APIError；业务码非成功时同时返回响应与 *APIError
+func call[T any](ctx context.Context, c *Client, cred Credential, method, path string, query url.Values, body interface{}) (*Response[T], error) {
+	if path == "" {
+		return nil, ErrEndpointNotConfigured
+	}
+
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s request: %w", path, err)
+		}
+	}
+
+	account := accountOf(cred, body)
+	var waited time.Duration
+	for attempt := 1; ; attempt++ {
+		result, err := do[T](ctx, c, cred, account, method, path, endpoint, data)
+		if err == nil || ctx.Err() != nil || attempt >= c.retry.MaxAttempts || !c.retry.retryable(err) {
+			return result, err
+		}
+		// 可以换凭证时限流立即返回，由调用方换账号，不在被限流的账号上等待重试
+		if errors.Is(err, ErrRateLimited) && canRotate(cred) {
+			return result, err
+		}
+
+		delay := c.retry.delay(attempt, err)
+		if !c.retry.withinBudget(waited, delay) {
+			c.log.Warn("ValueScan retry budget exhausted", map[string]interface{}{
+				"endpoint": path,
+				"attempt":  attempt,
+				"waited":   waited.String(),
+				"delay":    delay.String(),
+				"error":    err.Error(),
+			})
+			return result, err
+		}
+
+		c.log.Warn("ValueScan request failed, retrying", map[string]interface{}{
+			"endpoint": path,
+			"attempt":  attempt,
+			"delay":    delay.String(),
+			"error":    err.Error(),
+		})
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		waited += delay
+	}
+}
+
+// do 按限速等待后发送一次请求并解析响应
+func do[T any](ctx context.Context, c *Client, cred Credential, account, method, path, endpoint string, data []byte) (*Response[T], error) {
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+
+	// 先等待限速再附加凭证，避免排队期间令牌过期
+	wait, limitedBy, err := c.limiter.wait(ctx, path, account)
+	if err != nil {
+		return nil, err
+	}
+	if wait >= time.Millisecond {
+		c.log.Info("ValueScan request waited for rate limit", map[string]interface{}{
+			"endpoint":   path,
+			"limited_by": limitedBy,
+			"wait_ms":    wait.Milliseconds(),
+		})
+	}
+	if cred != nil {
+		if err := cred.Apply(req); err != nil {
+			return nil, err
+		}
+	}
+
+	// 接口熔断时快速失败，不再等待超时
+	breaker := c.breakers[path]
+	if err := breaker.allow(); err != nil {
+		return nil, err
+	}
+	result, err := send[T](c, req, path)
+	if ctx.Err() != nil {
+		// 调用方取消导致的失败不代表上游故障
+		breaker.abandon()
+	} else {
+		breaker.record(err)
+	}
+	return result, err
+}
+
+// send 发送请求并解析响应
+// 超时、连接错误与响应读取中断归为 ErrUpstream，调用方取消时保留原始的 ctx 错误
+func send[T any](c *Client, req *http.Request, path string) (*Response[T], error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, upstreamError(req, fmt.Errorf("failed to send request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, upstreamError(req, fmt.Errorf("failed to read response body: %w", err))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{
+			Endpoint:   path,
+			HTTPStatus: resp.StatusCode,
+			Msg:        truncate(respBody),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			codes:      c.errorCodes,
+		}
+		// 部分错误响应仍是标准结构，带上业务码与 reqId 便于排查
+		var meta Meta
+		if json.Unmarshal(respBody, &meta) == nil && meta.Code != 0 {
+			apiErr.Code, apiErr.Msg, apiErr.ReqID = meta.Code, meta.Msg, meta.ReqID
+		}
+		return nil, apiErr
+	}
+
+	var result Response[T]
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", path, ErrMalformedResponse, err)
+	}
+	if !result.OK() {
+		return &result, &APIError{
+			Endpoint:   path,
+			HTTPStatus: resp.StatusCode,
+			Code:       result.Code,
+			Msg:        result.Msg,
+			ReqID:      result.ReqID,
+			codes:      c.errorCodes,
+		}
+	}
+
+	return &result, nil
+}
+
+// upstreamError 将传输层错误归为 ErrUpstream，请求被调用方取消时原样返回
+func upstreamError(req *http.Request, err error) error {
+	if req.Context().Err() != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrUpstream, err)
+}
+
+// sleep 等待 d，ctx 取消时提前返回其错误
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// truncate 截断过长的响应体
+func truncate(body []byte) string {
+	if len(body) > maxErrorBody {
+		return string(body[:maxErrorBody]) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,135 @@
+package valuescan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// 错误分类，配合 errors.Is 使用；*APIError 按 HTTP 状态码与业务码归入其中之一
+var (
+	// ErrUnauthorized 凭证无效或无权限（HTTP 401/403 或 ErrorCodes.Unauthorized 中的业务码）
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited 请求被服务端限流（HTTP 429 或 ErrorCodes.RateLimited 中的业务码）
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNotFound 请求的资源不存在（HTTP 或业务码 404）
+	ErrNotFound = errors.New("not found")
+	// ErrUpstream 上游故障：HTTP 或业务码 5xx、超时、连接错误、响应读取中断
+	ErrUpstream = errors.New("upstream unavailable")
+	// ErrMalformedResponse 响应无法解析
+	ErrMalformedResponse = errors.New("malformed response")
+	// ErrEndpointNotConfigured 接口路径未配置，请求未发送
+	ErrEndpointNotConfigured = errors.New("endpoint path not configured")
+)
+
+// ErrorCodes 归入 ErrUnauthorized / ErrRateLimited 的业务码，HTTP 401/403/429 状态码始终归入对应分类
+type ErrorCodes struct {
+	Unauthorized []int // 令牌失效或无权限的业务码
+	RateLimited  []int // 账号或请求被限流的业务码
+}
+
+// DefaultErrorCodes 返回默认的业务码分类：与 HTTP 状态码同名的 401/403 与 429
+func DefaultErrorCodes() ErrorCodes {
+	return ErrorCodes{
+		Unauthorized: []int{http.StatusUnauthorized, http.StatusForbidden},
+		RateLimited:  []int{http.StatusTooManyRequests},
+	}
+}
+
+// WithErrorCodes 设置业务码分类，为空的列表使用默认值
+func WithErrorCodes(codes ErrorCodes) Option {
+	return func(c *Client) {
+		c.errorCodes = codes.withDefaults()
+	}
+}
+
+// withDefaults 为空的列表填充默认业务码
+func (c ErrorCodes) withDefaults() ErrorCodes {
+	defaults := DefaultErrorCodes()
+	if len(c.Unauthorized) == 0 {
+		c.Unauthorized = defaults.Unauthorized
+	}
+	if len(c.RateLimited) == 0 {
+		c.RateLimited = defaults.RateLimited
+	}
+	return c
+}
+
+// APIError 接口返回了非 200 的 HTTP 状态码或非成功的业务码
+// HTTP 错误的响应体能解析时同样带上业务码与 msg，否则 Msg 为截断后的响应体
+type APIError struct {
+	Endpoint   string
+	HTTPStatus int
+	Code       int // 业务码，响应体无法解析时为 0
+	Msg        string
+	ReqID      string
+	RetryAfter time.Duration // 响应头 Retry-After 要求的等待时间，没有时为 0
+
+	codes ErrorCodes // 客户端配置的业务码分类，为空时使用默认值
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s: code=%d, msg=%s", e.Endpoint, e.Code, e.Msg)
+	switch {
+	case e.HTTPStatus != http.StatusOK && e.Code == 0:
+		msg = fmt.Sprintf("%s: HTTP %d, body: %s", e.Endpoint, e.HTTPStatus, e.Msg)
+	case e.HTTPStatus != http.StatusOK:
+		msg = fmt.Sprintf("%s: HTTP %d, code=%d, msg=%s", e.Endpoint, e.HTTPStatus, e.Code, e.Msg)
+	}
+	if e.ReqID != "" {
+		msg += ", reqId=" + e.ReqID
+	}
+	return msg
+}
+
+// Is 使 errors.Is(err, ErrUnauthorized) 等按错误分类匹配
+func (e *APIError) Is(target error) bool {
+	class := e.class()
+	return class != nil && target == class
+}
+
+// class 返回错误分类，不属于任何分类（如其他 4xx 或业务码）时返回 nil
+func (e *APIError) class() error {
+	codes := e.codes.withDefaults()
+	switch {
+	case e.HTTPStatus == http.StatusUnauthorized || e.HTTPStatus == http.StatusForbidden,
+		slices.Contains(codes.Unauthorized, e.Code):
+		return ErrUnauthorized
+	case e.HTTPStatus == http.StatusTooManyRequests || slices.Contains(codes.RateLimited, e.Code):
+		return ErrRateLimited
+	case e.HTTPStatus == http.StatusNotFound || e.Code == http.StatusNotFound:
+		return ErrNotFound
+	case e.HTTPStatus >= http.StatusInternalServerError,
+		e.Code >= http.StatusInternalServerError && e.Code < 600:
+		return ErrUpstream
+	default:
+		return nil
+	}
+}
+
+// ErrorClass 返回错误的分类名，用于日志、告警与指标；未分类的错误返回 other
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrUpstream):
+		return "upstream"
+	case errors.Is(err, ErrMalformedResponse):
+		return "malformed_response"
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return "cancelled"
+	default:
+		return "other"
+	}
+}
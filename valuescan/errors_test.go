@@ -0,0 +1,87 @@
+package valuescan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorClassification(t *testing.T) {
+	custom := ErrorCodes{Unauthorized: []int{4001}, RateLimited: []int{4290}}
+	sentinels := []error{ErrUnauthorized, ErrRateLimited, ErrNotFound, ErrUpstream, ErrMalformedResponse}
+
+	tests := []struct {
+		name      string
+		err       *APIError
+		want      error // 为 nil 表示不属于任何分类
+		wantClass string
+	}{
+		{name: "http 401", err: &APIError{HTTPStatus: http.StatusUnauthorized}, want: ErrUnauthorized, wantClass: "unauthorized"},
+		{name: "http 403", err: &APIError{HTTPStatus: http.StatusForbidden}, want: ErrUnauthorized, wantClass: "unauthorized"},
+		{name: "default unauthorized code", err: &APIError{HTTPStatus: http.StatusOK, Code: 401}, want: ErrUnauthorized, wantClass: "unauthorized"},
+		{name: "custom unauthorized code", err: &APIError{HTTPStatus: http.StatusOK, Code: 4001, codes: custom}, want: ErrUnauthorized, wantClass: "unauthorized"},
+		{name: "custom code without config", err: &APIError{HTTPStatus: http.StatusOK, Code: 4001}, wantClass: "other"},
+		{name: "http 429", err: &APIError{HTTPStatus: http.StatusTooManyRequests}, want: ErrRateLimited, wantClass: "rate_limited"},
+		{name: "default rate limited code", err: &APIError{HTTPStatus: http.StatusOK, Code: 429}, want: ErrRateLimited, wantClass: "rate_limited"},
+		{name: "custom rate limited code", err: &APIError{HTTPStatus: http.StatusOK, Code: 4290, codes: custom}, want: ErrRateLimited, wantClass: "rate_limited"},
+		{name: "http 429 with custom codes", err: &APIError{HTTPStatus: http.StatusTooManyRequests, codes: custom}, want: ErrRateLimited, wantClass: "rate_limited"},
+		{name: "http 404", err: &APIError{HTTPStatus: http.StatusNotFound}, want: ErrNotFound, wantClass: "not_found"},
+		{name: "business 404", err: &APIError{HTTPStatus: http.StatusOK, Code: 404}, want: ErrNotFound, wantClass: "not_found"},
+		{name: "http 502", err: &APIError{HTTPStatus: http.StatusBadGateway}, want: ErrUpstream, wantClass: "upstream"},
+		{name: "business 503", err: &APIError{HTTPStatus: http.StatusOK, Code: 503}, want: ErrUpstream, wantClass: "upstream"},
+		{name: "http 400", err: &APIError{HTTPStatus: http.StatusBadRequest}, wantClass: "other"},
+		{name: "other business code", err: &APIError{HTTPStatus: http.StatusOK, Code: 4000}, wantClass: "other"},
+		{name: "business code above 5xx", err: &APIError{HTTPStatus: http.StatusOK, Code: 600}, wantClass: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("query coin: %w", tt.err)
+			for _, sentinel := range sentinels {
+				if got := errors.Is(wrapped, sentinel); got != (sentinel == tt.want) {
+					t.Fatalf("errors.Is(%v, %v) = %v, want %v", wrapped, sentinel, got, sentinel == tt.want)
+				}
+			}
+			if got := ErrorClass(wrapped); got != tt.wantClass {
+				t.Fatalf("ErrorClass = %q, want %q", got, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "circuit open", err: fmt.Errorf("%w: /api", ErrCircuitOpen), want: "circuit_open"},
+		{name: "malformed", err: fmt.Errorf("%w: unexpected token", ErrMalformedResponse), want: "malformed_response"},
+		{name: "upstream", err: fmt.Errorf("%w: connection reset", ErrUpstream), want: "upstream"},
+		{name: "canceled", err: context.Canceled, want: "cancelled"},
+		{name: "deadline", err: fmt.Errorf("wait: %w", context.DeadlineExceeded), want: "cancelled"},
+		{name: "endpoint not configured", err: ErrEndpointNotConfigured, want: "other"},
+		{name: "plain error", err: errors.New("boom"), want: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorClass(tt.err); got != tt.want {
+				t.Fatalf("ErrorClass(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithErrorCodesKeepsDefaultsForEmptyLists(t *testing.T) {
+	got := ErrorCodes{RateLimited: []int{4290}}.withDefaults()
+	if len(got.Unauthorized) != 2 || got.Unauthorized[0] != http.StatusUnauthorized || got.Unauthorized[1] != http.StatusForbidden {
+		t.Fatalf("Unauthorized = %v, want default [401 403]", got.Unauthorized)
+	}
+	if len(got.RateLimited) != 1 || got.RateLimited[0] != 4290 {
+		t.Fatalf("RateLimited = %v, want [4290]", got.RateLimited)
+	}
+}
@@ -0,0 +1,209 @@
+package valuescan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Fault 单个接口的故障注入规则，各比例取值 0-1，为 0 表示不注入该故障
+// 每个请求先增加延迟，再依次判断连接错误、状态码，请求成功后再判断响应损坏
+type Fault struct {
+	Latency       time.Duration // 每个请求增加的延迟
+	LatencyJitter time.Duration // 在 Latency 基础上随机增加 [0, LatencyJitter) 的抖动
+	ErrorRate     float64       // 不发送请求，直接返回连接重置错误
+	StatusRate    float64       // 不发送请求，直接返回 Status 状态码
+	Status        int           // 注入的 HTTP 状态码，为 0 时使用 503
+	RetryAfter    time.Duration // 注入状态码时附带的 Retry-After，为 0 不附带
+	TruncateRate  float64       // 响应体读到一半时中断
+	MalformedRate float64       // 响应体替换为无法解析的 JSON
+	EmptyDataRate float64       // 响应中的 data 替换为空字符串
+}
+
+// Faults 各接口的故障注入规则
+type Faults struct {
+	Login       Fault
+	Refresh     Fault
+	SendCode    Fault
+	QueryCoin   Fault
+	TradeInflow Fault
+}
+
+// FaultTransport 按接口注入延迟、错误、状态码与损坏的响应，用于测试与预发环境验证各类错误处理
+type FaultTransport struct {
+	// Logger 输出注入故障的日志，为空时不输出
+	Logger Logger
+
+	next   http.RoundTripper
+	faults map[string]Fault
+}
+
+// NewFaultTransport 创建故障注入传输层，按 endpoints 中的路径匹配规则；next 为空时使用 http.DefaultTransport
+func NewFaultTransport(next http.RoundTripper, endpoints Endpoints, faults Faults) *FaultTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &FaultTransport{
+		next: next,
+		faults: map[string]Fault{
+			endpoints.Login:       faults.Login,
+			endpoints.Refresh:     faults.Refresh,
+			endpoints.SendCode:    faults.SendCode,
+			endpoints.QueryCoin:   faults.QueryCoin,
+			endpoints.TradeInflow: faults.TradeInflow,
+		},
+	}
+}
+
+// RoundTrip 按规则注入故障，未匹配到规则的请求直接转发
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path, fault, ok := t.match(req.URL.Path)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	if delay := fault.Latency + jitter(fault.LatencyJitter); delay > 0 {
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+
+	if hit(fault.ErrorRate) {
+		t.logInjected(path, "connection_reset")
+		return nil, fmt.Errorf("fault injected: %w", syscall.ECONNRESET)
+	}
+	if hit(fault.StatusRate) {
+		t.logInjected(path, "status")
+		return faultResponse(req, fault), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	switch {
+	case hit(fault.TruncateRate):
+		t.logInjected(path, "truncated_body")
+		return corrupt(resp, truncateBody)
+	case hit(fault.MalformedRate):
+		t.logInjected(path, "malformed_json")
+		return corrupt(resp, func(body []byte) io.Reader {
+			return strings.NewReader(`{"code":200,"msg":"success","data":{`)
+		})
+	case hit(fault.EmptyDataRate):
+		t.logInjected(path, "empty_data")
+		return corrupt(resp, emptyData)
+	default:
+		return resp, nil
+	}
+}
+
+// match 按路径后缀匹配规则，baseURL 带路径前缀时同样生效
+// 多个接口路径都是后缀时取最长的一个，结果与 map 的遍历顺序无关
+func (t *FaultTransport) match(urlPath string) (string, Fault, bool) {
+	matched := ""
+	for path := range t.faults {
+		if len(path) > len(matched) && strings.HasSuffix(urlPath, path) {
+			matched = path
+		}
+	}
+	fault := t.faults[matched]
+	if matched == "" || fault == (Fault{}) {
+		return "", Fault{}, false
+	}
+	return matched, fault, true
+}
+
+// faultResponse 构造注入的错误状态码响应
+func faultResponse(req *http.Request, fault Fault) *http.Response {
+	status := fault.Status
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	if fault.RetryAfter > 0 {
+		header.Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Seconds())))
+	}
+
+	body := fmt.Sprintf(`{"code":%d,"msg":"fault injected","data":""}`, status)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// corrupt 读取响应体并按 fn 替换
+func corrupt(resp *http.Response, fn func(body []byte) io.Reader) (*http.Response, error) {
+	body, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(fn(body))
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// truncateBody 只返回前一半响应体，之后报告连接中断
+func truncateBody(body []byte) io.Reader {
+	return io.MultiReader(bytes.NewReader(body[:len(body)/2]), errReader{io.ErrUnexpectedEOF})
+}
+
+// emptyData 将 data 替换为空字符串，模拟服务端失败时返回的 data: ""
+func emptyData(body []byte) io.Reader {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return bytes.NewReader(body)
+	}
+	resp["data"] = json.RawMessage(`""`)
+	out, err := json.Marshal(resp)
+	if err != nil {
+		return bytes.NewReader(body)
+	}
+	return bytes.NewReader(out)
+}
+
+// errReader 读取时返回固定错误
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// hit 按比例随机判断是否注入
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// jitter 返回 [0, d) 的随机时长
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// logInjected 记录一次注入的故障
+func (t *FaultTransport) logInjected(path, kind string) {
+	orNop(t.Logger).Info("ValueScan fault injected", map[string]interface{}{
+		"endpoint": path,
+		"fault":    kind,
+	})
+}
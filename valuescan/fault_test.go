@@ -0,0 +1,142 @@
+package valuescan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newFaultClient 创建请求本地假服务的客户端，QueryCoin 接口按 fault 注入故障；不重试，每种故障只观察一次
+func newFaultClient(t *testing.T, fault Fault) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"code":200,"msg":"success","reqId":"req-1","data":{"total":5,"list":[{"vsTokenId":"1","symbol":"BTC"}]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewClient(server.URL,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithTransport(NewFaultTransport(nil, DefaultEndpoints(), Faults{QueryCoin: fault})),
+	)
+}
+
+func TestFaultTransportInjectsThroughClient(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault Fault
+		check func(t *testing.T, resp *Response[CoinPage], err error)
+	}{
+		{
+			name:  "connection reset",
+			fault: Fault{ErrorRate: 1},
+			check: func(t *testing.T, resp *Response[CoinPage], err error) {
+				if !errors.Is(err, ErrUpstream) || !errors.Is(err, syscall.ECONNRESET) {
+					t.Fatalf("err = %v, want ErrUpstream wrapping ECONNRESET", err)
+				}
+			},
+		},
+		{
+			name:  "default status",
+			fault: Fault{StatusRate: 1},
+			check: func(t *testing.T, resp *Response[CoinPage], err error) {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusServiceUnavailable || !errors.Is(err, ErrUpstream) {
+					t.Fatalf("err = %v, want HTTP 503 classified as ErrUpstream", err)
+				}
+			},
+		},
+		{
+			name:  "rate limited with retry after",
+			fault: Fault{StatusRate: 1, Status: http.StatusTooManyRequests, RetryAfter: 2 * time.Second},
+			check: func(t *testing.T, resp *Response[CoinPage], err error) {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || !errors.Is(err, ErrRateLimited) {
+					t.Fatalf("err = %v, want ErrRateLimited", err)
+				}
+				if apiErr.RetryAfter != 2*time.Second {
+					t.Fatalf("RetryAfter = %s, want 2s", apiErr.RetryAfter)
+				}
+			},
+		},
+		{
+			name:  "truncated body",
+			fault: Fault{TruncateRate: 1},
+			check: func(t *testing.T, resp *Response[CoinPage], err error) {
+				if !errors.Is(err, ErrUpstream) {
+					t.Fatalf("err = %v, want ErrUpstream", err)
+				}
+			},
+		},
+		{
+			name:  "malformed json",
+			fault: Fault{MalformedRate: 1},
+			check: func(t *testing.T, resp *Response[CoinPage], err error) {
+				if !errors.Is(err, ErrMalformedResponse) {
+					t.Fatalf("err = %v, want ErrMalformedResponse", err)
+				}
+			},
+		},
+		{
+			name:  "empty data",
+			fault: Fault{EmptyDataRate: 1},
+			check: func(t *testing.T, resp *Response[CoinPage], err error) {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				if resp.Data.Total != 0 || len(resp.Data.List) != 0 || resp.ReqID != "req-1" {
+					t.Fatalf("resp = %+v, want empty data with original meta", resp)
+				}
+			},
+		},
+		{
+			name:  "latency",
+			fault: Fault{Latency: 50 * time.Millisecond},
+			check: func(t *testing.T, resp *Response[CoinPage], err error) {
+				if err != nil || resp.Data.Total != 5 {
+					t.Fatalf("resp = %+v, err = %v, want the upstream response", resp, err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFaultClient(t, tt.fault)
+
+			start := time.Now()
+			resp, err := client.QueryCoin(context.Background(), nil, QueryCoinRequest{Page: 1, PageSize: 10})
+			if elapsed := time.Since(start); elapsed < tt.fault.Latency {
+				t.Fatalf("request took %s, want at least %s", elapsed, tt.fault.Latency)
+			}
+			tt.check(t, resp, err)
+		})
+	}
+}
+
+func TestFaultTransportMatchesLongestPath(t *testing.T) {
+	transport := NewFaultTransport(nil, Endpoints{
+		Login:     "/queryCoin",
+		QueryCoin: "/api/vs-token/queryCoin",
+	}, Faults{
+		Login:     Fault{StatusRate: 1, Status: http.StatusUnauthorized},
+		QueryCoin: Fault{StatusRate: 1, Status: http.StatusBadGateway},
+	})
+
+	for range 50 {
+		path, fault, ok := transport.match("/proxy/api/vs-token/queryCoin")
+		if !ok || path != "/api/vs-token/queryCoin" || fault.Status != http.StatusBadGateway {
+			t.Fatalf("match = %q, %+v, %v; want the queryCoin rule", path, fault, ok)
+		}
+	}
+
+	if _, _, ok := transport.match("/api/trade/getCoinTradeInflow"); ok {
+		t.Fatal("unconfigured endpoint matched a fault rule")
+	}
+}
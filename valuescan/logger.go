@@ -0,0 +1,30 @@
+package valuescan
+
+// Logger 客户端输出熔断、重试、限速等事件的日志接口，utils/logger.Logger 满足该接口
+type Logger interface {
+	Info(msg string, fields ...map[string]interface{})
+	Warn(msg string, fields ...map[string]interface{})
+	Error(msg string, fields ...map[string]interface{})
+}
+
+// WithLogger 设置事件日志输出，默认不输出
+func WithLogger(log Logger) Option {
+	return func(c *Client) {
+		c.log = orNop(log)
+	}
+}
+
+// nopLogger 丢弃全部日志
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...map[string]interface{})  {}
+func (nopLogger) Warn(string, ...map[string]interface{})  {}
+func (nopLogger) Error(string, ...map[string]interface{}) {}
+
+// orNop 为空时返回丢弃日志的 Logger
+func orNop(log Logger) Logger {
+	if log == nil {
+		return nopLogger{}
+	}
+	return log
+}
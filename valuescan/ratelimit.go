@@ -0,0 +1,170 @@
+package valuescan
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limit 令牌桶参数：Rate 为每秒请求数，Burst 为允许的突发请求数；Rate 为 0 表示不限速
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimits 客户端限速配置
+// 各接口的限速由所有账号共享，Account 对每个账号单独计数（所有接口合计）
+type RateLimits struct {
+	Login       Limit
+	Refresh     Limit
+	SendCode    Limit
+	QueryCoin   Limit
+	TradeInflow Limit
+	Account     Limit
+}
+
+// AccountScoped 可识别所属账号的凭证或请求，用于按账号限速
+type AccountScoped interface {
+	Account() string
+}
+
+// WithRateLimits 设置按接口与按账号的限速
+func WithRateLimits(limits RateLimits) Option {
+	return func(c *Client) {
+		c.limits = limits
+	}
+}
+
+// tokenBucket 令牌桶，令牌可以透支，透支的部分即排队等待的时间
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time // 时钟，测试时可替换
+}
+
+// newTokenBucket 创建令牌桶，不限速时返回 nil
+func newTokenBucket(limit Limit) *tokenBucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	burst := float64(max(limit.Burst, 1))
+	return &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst, last: time.Now(), now: time.Now}
+}
+
+// reserve 取走一个令牌，返回拿到令牌前需要等待的时间
+func (b *tokenBucket) reserve() time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel 归还 reserve 取走但未使用的令牌，之后的请求可以少等待一个令牌的时间
+func (b *tokenBucket) cancel() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// rateLimiter 按接口与按账号的令牌桶
+type rateLimiter struct {
+	endpoints map[string]*tokenBucket
+	account   Limit
+
+	mu       sync.Mutex
+	accounts map[string]*tokenBucket
+}
+
+// newRateLimiter 按已配置的接口路径建立令牌桶
+func newRateLimiter(endpoints Endpoints, limits RateLimits) *rateLimiter {
+	l := &rateLimiter{
+		endpoints: make(map[string]*tokenBucket),
+		account:   limits.Account,
+		accounts:  make(map[string]*tokenBucket),
+	}
+	for _, e := range []struct {
+		path  string
+		limit Limit
+	}{
+		{endpoints.Login, limits.Login},
+		{endpoints.Refresh, limits.Refresh},
+		{endpoints.SendCode, limits.SendCode},
+		{endpoints.QueryCoin, limits.QueryCoin},
+		{endpoints.TradeInflow, limits.TradeInflow},
+	} {
+		// 未配置的接口路径为空，跳过以免多个空路径共用同一个键互相覆盖
+		if e.path == "" {
+			continue
+		}
+		l.endpoints[e.path] = newTokenBucket(e.limit)
+	}
+	return l
+}
+
+// accountBucket 返回账号的令牌桶，首次使用时创建
+func (l *rateLimiter) accountBucket(account string) *tokenBucket {
+	if account == "" || l.account.Rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.accounts[account]
+	if !ok {
+		bucket = newTokenBucket(l.account)
+		l.accounts[account] = bucket
+	}
+	return bucket
+}
+
+// wait 等待接口与账号的令牌都可用，返回等待时间及受限的一方（endpoint / account）
+// ctx 取消时归还已预留的令牌，避免取消的请求继续占用后续请求的配额
+func (l *rateLimiter) wait(ctx context.Context, path, account string) (time.Duration, string, error) {
+	endpointBucket, accountBucket := l.endpoints[path], l.accountBucket(account)
+	endpointWait := endpointBucket.reserve()
+	accountWait := accountBucket.reserve()
+
+	wait, limitedBy := endpointWait, "endpoint"
+	if accountWait > endpointWait {
+		wait, limitedBy = accountWait, "account"
+	}
+	if wait > 0 {
+		if err := sleep(ctx, wait); err != nil {
+			endpointBucket.cancel()
+			accountBucket.cancel()
+			return wait, limitedBy, err
+		}
+	}
+	return wait, limitedBy, nil
+}
+
+// accountOf 从凭证或请求体中识别账号
+func accountOf(cred Credential, body interface{}) string {
+	if scoped, ok := cred.(AccountScoped); ok {
+		return scoped.Account()
+	}
+	if scoped, ok := body.(AccountScoped); ok {
+		return scoped.Account()
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package valuescan
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动拨动的时钟
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newTestBucket 创建使用 fakeClock 的令牌桶
+func newTestBucket(limit Limit, clock *fakeClock) *tokenBucket {
+	b := newTokenBucket(limit)
+	b.now = clock.now
+	b.last = clock.now()
+	return b
+}
+
+func TestTokenBucketReserveAndCancel(t *testing.T) {
+	type step struct {
+		advance  time.Duration
+		cancel   bool
+		wantWait time.Duration // reserve 的等待时间，cancel 步骤忽略
+	}
+
+	tests := []struct {
+		name  string
+		limit Limit
+		steps []step
+	}{
+		{
+			name:  "burst then queue",
+			limit: Limit{Rate: 2, Burst: 2},
+			steps: []step{
+				{wantWait: 0},
+				{wantWait: 0},
+				{wantWait: 500 * time.Millisecond},
+				{wantWait: time.Second},
+			},
+		},
+		{
+			name:  "refill over time",
+			limit: Limit{Rate: 1, Burst: 1},
+			steps: []step{
+				{wantWait: 0},
+				{wantWait: time.Second},
+				{advance: 3 * time.Second, wantWait: 0},
+			},
+		},
+		{
+			name:  "refill capped at burst",
+			limit: Limit{Rate: 1, Burst: 2},
+			steps: []step{
+				{advance: time.Hour, wantWait: 0},
+				{wantWait: 0},
+				{wantWait: time.Second},
+			},
+		},
+		{
+			name:  "cancel returns queued token",
+			limit: Limit{Rate: 1, Burst: 1},
+			steps: []step{
+				{wantWait: 0},
+				{wantWait: time.Second},
+				{cancel: true},
+				{wantWait: time.Second},
+			},
+		},
+		{
+			name:  "cancel capped at burst",
+			limit: Limit{Rate: 1, Burst: 1},
+			steps: []step{
+				{cancel: true},
+				{cancel: true},
+				{wantWait: 0},
+				{wantWait: time.Second},
+			},
+		},
+		{
+			name:  "zero burst treated as one",
+			limit: Limit{Rate: 4},
+			steps: []step{
+				{wantWait: 0},
+				{wantWait: 250 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{t: time.Unix(1700000000, 0)}
+			b := newTestBucket(tt.limit, clock)
+
+			for i, s := range tt.steps {
+				clock.advance(s.advance)
+				if s.cancel {
+					b.cancel()
+					continue
+				}
+				if got := b.reserve(); got != s.wantWait {
+					t.Fatalf("step %d: reserve() = %s, want %s", i, got, s.wantWait)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenBucketUnlimited(t *testing.T) {
+	b := newTokenBucket(Limit{})
+	if b != nil {
+		t.Fatalf("newTokenBucket(Limit{}) = %+v, want nil", b)
+	}
+	if got := b.reserve(); got != 0 {
+		t.Fatalf("nil bucket reserve() = %s, want 0", got)
+	}
+	b.cancel()
+}
+
+func TestRateLimiterCancelledWaitReturnsTokens(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	endpoint := newTestBucket(Limit{Rate: 1, Burst: 1}, clock)
+	account := newTestBucket(Limit{Rate: 1, Burst: 1}, clock)
+	limiter := &rateLimiter{
+		endpoints: map[string]*tokenBucket{"/query": endpoint},
+		account:   Limit{Rate: 1, Burst: 1},
+		accounts:  map[string]*tokenBucket{"user": account},
+	}
+
+	if wait, _, err := limiter.wait(context.Background(), "/query", "user"); wait != 0 || err != nil {
+		t.Fatalf("first wait = %s, %v; want 0, nil", wait, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if wait, _, err := limiter.wait(ctx, "/query", "user"); wait != time.Second || err == nil {
+		t.Fatalf("cancelled wait = %s, %v; want 1s and an error", wait, err)
+	}
+
+	// 取消的请求归还了令牌，下一个请求只需等待一个令牌的时间
+	if got := endpoint.reserve(); got != time.Second {
+		t.Fatalf("endpoint reserve() = %s, want 1s", got)
+	}
+	if got := account.reserve(); got != time.Second {
+		t.Fatalf("account reserve() = %s, want 1s", got)
+	}
+}
@@ -0,0 +1,48 @@
+package valuescan
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// CodeSuccess 业务成功码
+const CodeSuccess = 200
+
+// Meta 响应中除 data 以外的公共字段
+type Meta struct {
+	Code     int    `json:"code"`
+	Msg      string `json:"msg"`
+	ReqID    string `json:"reqId"`
+	UserRole string `json:"userRole"`
+}
+
+// OK 判断业务码是否成功
+func (m Meta) OK() bool {
+	return m.Code == CodeSuccess
+}
+
+// Response ValueScan 接口的通用响应
+type Response[T any] struct {
+	Meta
+	Data T `json:"data"`
+}
+
+// UnmarshalJSON 解析响应；失败时 data 常为空字符串，此时 Data 保持零值
+func (r *Response[T]) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Meta
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	r.Meta = raw.Meta
+
+	data := bytes.TrimSpace(raw.Data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) || bytes.Equal(data, []byte(`""`)) {
+		var zero T
+		r.Data = zero
+		return nil
+	}
+	return json.Unmarshal(data, &r.Data)
+}
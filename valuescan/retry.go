@@ -0,0 +1,121 @@
+package valuescan
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy 请求失败时的重试策略
+// 超时、连接重置、5xx、429 与 RetryableCodes 中的业务码会按指数退避加抖动重试，
+// 其余错误（如 4xx、认证失败、响应无法解析）立即返回
+type RetryPolicy struct {
+	MaxAttempts    int           // 每次调用最多请求的次数（含第一次），1 表示不重试
+	BaseDelay      time.Duration // 第一次重试前的退避时间，之后每次翻倍
+	MaxDelay       time.Duration // 单次退避的上限
+	Budget         time.Duration // 每次调用用于等待重试的总时间，0 表示不限制
+	RetryableCodes []int         // 可重试的业务码
+}
+
+// DefaultRetryPolicy 返回默认的重试策略
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		BaseDelay:      time.Second,
+		MaxDelay:       30 * time.Second,
+		Budget:         time.Minute,
+		RetryableCodes: []int{429, 500, 502, 503, 504},
+	}
+}
+
+// WithRetryPolicy 设置重试策略
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// Rotatable 可以换一份凭证重试的凭证（如账号池中的账号）
+// CanRotate 返回 true 时，限流错误不在同一凭证上重试，立即返回 ErrRateLimited
+type Rotatable interface {
+	CanRotate() bool
+}
+
+// canRotate 判断凭证被限流时能否换一份凭证
+func canRotate(cred Credential) bool {
+	r, ok := cred.(Rotatable)
+	return ok && r.CanRotate()
+}
+
+// retryable 判断错误是否值得重试
+func (p RetryPolicy) retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.HTTPStatus != http.StatusOK {
+			return apiErr.HTTPStatus == http.StatusTooManyRequests || apiErr.HTTPStatus >= http.StatusInternalServerError
+		}
+		for _, code := range p.RetryableCodes {
+			if apiErr.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// backoff 返回第 attempt 次重试（从 1 开始）前的等待时间，在 [d/2, d) 之间随机抖动
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half)
+}
+
+// delay 返回第 attempt 次请求失败后的等待时间：服务端给出 Retry-After 时按其等待，否则指数退避
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	return p.backoff(attempt)
+}
+
+// withinBudget 判断已等待 waited 后再等待 delay 是否仍在重试预算内
+func (p RetryPolicy) withinBudget(waited, delay time.Duration) bool {
+	return p.Budget <= 0 || waited+delay <= p.Budget
+}
+
+// parseRetryAfter 解析 Retry-After 响应头（秒数或 HTTP 日期），无法解析时返回 0
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
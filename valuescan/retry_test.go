@@ -0,0 +1,177 @@
+package valuescan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration // 抖动前的退避时间，结果落在 [want/2, want)
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 3, want: 4 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 5, want: 10 * time.Second},
+		{attempt: 40, want: 10 * time.Second},
+		{attempt: 80, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			for range 100 {
+				got := policy.backoff(tt.attempt)
+				if got < tt.want/2 || got >= tt.want {
+					t.Fatalf("backoff(%d) = %s, want in [%s, %s)", tt.attempt, got, tt.want/2, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayAndBudget(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second, Budget: time.Minute}
+
+	tests := []struct {
+		name       string
+		err        error
+		waited     time.Duration
+		wantDelay  time.Duration // 为 0 时按退避区间检查
+		wantWithin bool
+	}{
+		{name: "backoff", err: io.ErrUnexpectedEOF, wantWithin: true},
+		{name: "retry after", err: &APIError{HTTPStatus: http.StatusTooManyRequests, RetryAfter: 20 * time.Second}, wantDelay: 20 * time.Second, wantWithin: true},
+		{name: "retry after beyond max delay", err: &APIError{HTTPStatus: http.StatusTooManyRequests, RetryAfter: 45 * time.Second}, wantDelay: 45 * time.Second, wantWithin: true},
+		{name: "retry after exceeds remaining budget", err: &APIError{HTTPStatus: http.StatusTooManyRequests, RetryAfter: 45 * time.Second}, waited: 30 * time.Second, wantDelay: 45 * time.Second, wantWithin: false},
+		{name: "retry after exceeds budget", err: &APIError{HTTPStatus: http.StatusServiceUnavailable, RetryAfter: 2 * time.Minute}, wantDelay: 2 * time.Minute, wantWithin: false},
+		{name: "retry after fills budget exactly", err: &APIError{HTTPStatus: http.StatusServiceUnavailable, RetryAfter: 50 * time.Second}, waited: 10 * time.Second, wantDelay: 50 * time.Second, wantWithin: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.delay(1, tt.err)
+			if tt.wantDelay > 0 && got != tt.wantDelay {
+				t.Fatalf("delay = %s, want %s", got, tt.wantDelay)
+			}
+			if tt.wantDelay == 0 && (got < policy.BaseDelay/2 || got >= policy.BaseDelay) {
+				t.Fatalf("delay = %s, want backoff in [%s, %s)", got, policy.BaseDelay/2, policy.BaseDelay)
+			}
+			if within := policy.withinBudget(tt.waited, got); within != tt.wantWithin {
+				t.Fatalf("withinBudget(%s, %s) = %v, want %v", tt.waited, got, within, tt.wantWithin)
+			}
+		})
+	}
+
+	if unlimited := (RetryPolicy{}); !unlimited.withinBudget(time.Hour, time.Hour) {
+		t.Fatal("zero budget should not limit retries")
+	}
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	policy := DefaultRetryPolicy()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "http 429", err: &APIError{HTTPStatus: http.StatusTooManyRequests}, want: true},
+		{name: "http 500", err: &APIError{HTTPStatus: http.StatusInternalServerError}, want: true},
+		{name: "http 503", err: &APIError{HTTPStatus: http.StatusServiceUnavailable}, want: true},
+		{name: "http 401", err: &APIError{HTTPStatus: http.StatusUnauthorized}, want: false},
+		{name: "http 404", err: &APIError{HTTPStatus: http.StatusNotFound}, want: false},
+		{name: "retryable business code", err: &APIError{HTTPStatus: http.StatusOK, Code: 503}, want: true},
+		{name: "other business code", err: &APIError{HTTPStatus: http.StatusOK, Code: 4000}, want: false},
+		{name: "wrapped api error", err: fmt.Errorf("query: %w", &APIError{HTTPStatus: http.StatusBadGateway}), want: true},
+		{name: "timeout", err: os.ErrDeadlineExceeded, want: true},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{name: "unexpected eof", err: io.ErrUnexpectedEOF, want: true},
+		{name: "eof", err: io.EOF, want: true},
+		{name: "malformed response", err: ErrMalformedResponse, want: false},
+		{name: "other error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.retryable(tt.err); got != tt.want {
+				t.Fatalf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "5", want: 5 * time.Second},
+		{value: "-1", want: 0},
+		{value: "soon", want: 0},
+		{value: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got != tt.want {
+				t.Fatalf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got <= 50*time.Second || got > time.Minute {
+		t.Fatalf("parseRetryAfter(%q) = %s, want about 1m", future, got)
+	}
+}
+
+// rotatableCredential 可以换号的测试凭证
+type rotatableCredential struct{ rotate bool }
+
+func (rotatableCredential) Apply(*http.Request) error { return nil }
+func (c rotatableCredential) CanRotate() bool         { return c.rotate }
+
+func TestClientRateLimitRetryDependsOnRotation(t *testing.T) {
+	tests := []struct {
+		name         string
+		rotate       bool
+		wantRequests int64
+	}{
+		{name: "rotatable credential returns immediately", rotate: true, wantRequests: 1},
+		{name: "single credential retries", rotate: false, wantRequests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}))
+			_, err := client.QueryCoin(context.Background(), rotatableCredential{rotate: tt.rotate}, QueryCoinRequest{Page: 1, PageSize: 10})
+			if !errors.Is(err, ErrRateLimited) {
+				t.Fatalf("err = %v, want ErrRateLimited", err)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
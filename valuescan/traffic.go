@@ -0,0 +1,320 @@
+package valuescan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// redacted 录制文件中替换敏感值的占位符
+const redacted = "[REDACTED]"
+
+var (
+	// redactedHeaders 录制时隐藏的请求头（不区分大小写）
+	redactedHeaders = []string{"Authorization", "AccessToken", "Cookie", "Set-Cookie"}
+	// redactedRequestFields 录制时隐藏的请求体字段（登录码、刷新令牌）
+	redactedRequestFields = map[string]bool{"code": true, "refreshToken": true}
+	// redactedResponseFields 录制时隐藏的响应体字段（登录与刷新返回的令牌）
+	redactedResponseFields = map[string]bool{"account_token": true, "refresh_token": true}
+)
+
+// Exchange 一次录制的请求与响应
+type Exchange struct {
+	RecordedAt      time.Time       `json:"recordedAt"`
+	Method          string          `json:"method"`
+	Path            string          `json:"path"`
+	Query           string          `json:"query,omitempty"`
+	RequestHeaders  http.Header     `json:"requestHeaders,omitempty"`
+	RequestBody     json.RawMessage `json:"requestBody,omitempty"`
+	RequestText     string          `json:"requestText,omitempty"` // 非 JSON 的请求体
+	Status          int             `json:"status"`
+	ResponseHeaders http.Header     `json:"responseHeaders,omitempty"`
+	ResponseBody    json.RawMessage `json:"responseBody,omitempty"`
+	ResponseText    string          `json:"responseText,omitempty"` // 非 JSON 的响应体，如网关错误页
+}
+
+// key 回放时匹配录制的键：方法、路径、查询参数与脱敏后的请求体（忽略 JSON 格式差异）
+func (e *Exchange) key() string {
+	var body bytes.Buffer
+	if err := json.Compact(&body, e.RequestBody); err != nil {
+		body.Reset()
+		body.Write(e.RequestBody)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", e.Method, e.Path, e.Query)
+	h.Write(body.Bytes())
+	h.Write([]byte(e.RequestText))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// WithTransport 设置底层 http.RoundTripper，如录制或回放
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		httpClient := *c.httpClient
+		httpClient.Transport = transport
+		c.httpClient = &httpClient
+	}
+}
+
+// RecordingTransport 转发请求并把每次请求与响应写入目录，认证头与令牌会被隐藏
+type RecordingTransport struct {
+	// Logger 输出写入录制失败的日志，为空时不输出
+	Logger Logger
+
+	dir  string
+	next http.RoundTripper
+	seq  atomic.Int64 // 最近使用的录制编号
+}
+
+// NewRecordingTransport 创建录制传输层，next 为空时使用 http.DefaultTransport
+func NewRecordingTransport(dir string, next http.RoundTripper) (*RecordingTransport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	// 接着目录中已有的最大录制编号，多次运行的录制按时间顺序排列
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	t := &RecordingTransport{dir: dir, next: next}
+	for _, file := range existing {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "-")
+		if n, err := strconv.ParseInt(prefix, 10, 64); err == nil && n > t.seq.Load() {
+			t.seq.Store(n)
+		}
+	}
+	return t, nil
+}
+
+// RoundTrip 发送请求并录制，写文件失败不影响请求结果
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange := &Exchange{
+		RecordedAt:      time.Now(),
+		Method:          req.Method,
+		Path:            req.URL.Path,
+		Query:           req.URL.RawQuery,
+		RequestHeaders:  redactHeaders(req.Header),
+		Status:          resp.StatusCode,
+		ResponseHeaders: redactHeaders(resp.Header),
+	}
+	exchange.RequestBody, exchange.RequestText = redactJSON(reqBody, redactedRequestFields)
+	exchange.ResponseBody, exchange.ResponseText = redactJSON(respBody, redactedResponseFields)
+	if err := t.write(exchange); err != nil {
+		orNop(t.Logger).Error("Failed to record ValueScan exchange", map[string]interface{}{
+			"path":  exchange.Path,
+			"error": err.Error(),
+		})
+	}
+
+	return resp, nil
+}
+
+// write 写入一条录制，文件名为递增编号加接口路径
+func (t *RecordingTransport) write(exchange *Exchange) error {
+	data, err := json.MarshalIndent(exchange, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+
+	// O_EXCL 保证不覆盖已有录制，编号被其它进程占用时顺延
+	slug := strings.ReplaceAll(strings.Trim(exchange.Path, "/"), "/", "_")
+	for {
+		name := filepath.Join(t.dir, fmt.Sprintf("%06d-%s.json", t.seq.Add(1), slug))
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create recording: %w", err)
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write recording: %w", err)
+		}
+		return nil
+	}
+}
+
+// ReplayTransport 用录制的响应代替网络请求
+// 相同请求有多条录制时按录制顺序依次返回，用完后重复最后一条
+type ReplayTransport struct {
+	mu        sync.Mutex
+	exchanges map[string][]*Exchange
+	served    map[string]int
+}
+
+// NewReplayTransport 加载目录中的全部录制
+func NewReplayTransport(dir string) (*ReplayTransport, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recordings found in %s", dir)
+	}
+	sort.Strings(files)
+
+	t := &ReplayTransport{
+		exchanges: make(map[string][]*Exchange),
+		served:    make(map[string]int),
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording: %w", err)
+		}
+		var exchange Exchange
+		if err := json.Unmarshal(data, &exchange); err != nil {
+			return nil, fmt.Errorf("failed to parse recording %s: %w", file, err)
+		}
+		key := exchange.key()
+		t.exchanges[key] = append(t.exchanges[key], &exchange)
+	}
+	return t, nil
+}
+
+// RoundTrip 按请求查找录制并构造响应，找不到时返回错误
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := &Exchange{Method: req.Method, Path: req.URL.Path, Query: req.URL.RawQuery}
+	lookup.RequestBody, lookup.RequestText = redactJSON(reqBody, redactedRequestFields)
+	key := lookup.key()
+
+	t.mu.Lock()
+	recorded := t.exchanges[key]
+	if len(recorded) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("replay: no recording for %s %s", req.Method, req.URL.RequestURI())
+	}
+	exchange := recorded[min(t.served[key], len(recorded)-1)]
+	t.served[key]++
+	t.mu.Unlock()
+
+	header := exchange.ResponseHeaders.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	// 脱敏后响应体长度可能变化，以实际返回的内容为准
+	header.Del("Content-Length")
+	body := []byte(exchange.ResponseBody)
+	if len(body) == 0 {
+		body = []byte(exchange.ResponseText)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Status, http.StatusText(exchange.Status)),
+		StatusCode:    exchange.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// readBody 读取并替换请求或响应体，使其仍可被后续读取
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// redactHeaders 复制请求头并隐藏认证相关的值
+func redactHeaders(header http.Header) http.Header {
+	redactedHeader := header.Clone()
+	for _, name := range redactedHeaders {
+		if redactedHeader.Get(name) != "" {
+			redactedHeader.Set(name, redacted)
+		}
+	}
+	return redactedHeader
+}
+
+// redactJSON 隐藏 JSON 中指定字段的值（任意层级），非 JSON 内容原样作为文本返回
+func redactJSON(data []byte, fields map[string]bool) (json.RawMessage, string) {
+	if len(data) == 0 {
+		return nil, ""
+	}
+
+	// 保留数字原文，避免大整数 ID 经 float64 丢失精度
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil || decoder.More() {
+		return nil, string(data)
+	}
+	redactValue(v, fields)
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, string(data)
+	}
+	return out, ""
+}
+
+// redactValue 递归替换字段值
+func redactValue(v interface{}, fields map[string]bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if fields[k] {
+				val[k] = redacted
+				continue
+			}
+			redactValue(child, fields)
+		}
+	case []interface{}:
+		for _, child := range val {
+			redactValue(child, fields)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package valuescan
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	PhoneOrEmail  string `json:"phoneOrEmail"`
+	Code          string `json:"code"`
+	EndpointEnum  int    `json:"endpointEnum"`
+	LoginTypeEnum int    `json:"loginTypeEnum"`
+}
+
+// Account 返回登录的账号，用于按账号限速
+func (r LoginRequest) Account() string {
+	return r.PhoneOrEmail
+}
+
+// SendCodeRequest 发送登录验证码请求
+type SendCodeRequest struct {
+	PhoneOrEmail  string `json:"phoneOrEmail"`
+	EndpointEnum  int    `json:"endpointEnum"`
+	LoginTypeEnum int    `json:"loginTypeEnum"`
+}
+
+// Account 返回接收验证码的账号，用于按账号限速
+func (r SendCodeRequest) Account() string {
+	return r.PhoneOrEmail
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+	PhoneOrEmail string `json:"-"` // 令牌所属账号，只用于按账号限速，不随请求发送
+}
+
+// Account 返回令牌所属的账号，用于按账号限速
+func (r RefreshRequest) Account() string {
+	return r.PhoneOrEmail
+}
+
+// LoginData 登录与刷新令牌接口返回的令牌
+type LoginData struct {
+	AccountToken string `json:"account_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// QueryCoinRequest 币种查询请求
+type QueryCoinRequest struct {
+	Search    string `json:"search"`
+	IsBinance bool   `json:"isBinance"`
+	Page      int    `json:"page"`
+	PageSize  int    `json:"pageSize"`
+}
+
+// CoinPage 币种查询结果
+type CoinPage struct {
+	Total  int             `json:"total"`
+	List   []Coin          `json:"list"`
+	Extend json.RawMessage `json:"extend"`
+}
+
+// Coin 币种信息
+type Coin struct {
+	VSTokenID string `json:"vsTokenId"`
+	Name      string `json:"name"`
+	Symbol    string `json:"symbol"`
+	MarketCap string `json:"marketCap"`
+}
+
+// TradeInflow 资金流向查询结果
+type TradeInflow struct {
+	Symbol string            `json:"symbol"`
+	List   []TradeInflowItem `json:"coinTradeInflowDtoList"`
+}
+
+// TradeInflowItem 单个时间粒度的资金流向
+type TradeInflowItem struct {
+	TimeParticleEnum          Int    `json:"timeParticleEnum"`
+	Time                      String `json:"time"`
+	Stop                      Bool   `json:"stop"`
+	StopTradeInflow           Float  `json:"stopTradeInflow"`
+	StopTradeAmount           Float  `json:"stopTradeAmount"`
+	StopTradeInflowChange     Float  `json:"stopTradeInflowChange"`
+	StopTradeAmountChange     Float  `json:"stopTradeAmountChange"`
+	Contract                  Bool   `json:"contract"`
+	ContractTradeInflow       Float  `json:"contractTradeInflow"`
+	ContractTradeAmount       Float  `json:"contractTradeAmount"`
+	ContractTradeInflowChange Float  `json:"contractTradeInflowChange"`
+	ContractTradeAmountChange Float  `json:"contractTradeAmountChange"`
+	StopTradeIn               Float  `json:"stopTradeIn"`
+	StopTradeOut              Float  `json:"stopTradeOut"`
+	ContractTradeIn           Float  `json:"contractTradeIn"`
+	ContractTradeOut          Float  `json:"contractTradeOut"`
+}
+
+// Float 兼容数字与数字字符串的浮点数，无法解析时为 0
+type Float float64
+
+func (f *Float) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseFloat(unquote(b), 64)
+	if err != nil {
+		v = 0
+	}
+	*f = Float(v)
+	return nil
+}
+
+// Int 兼容数字与数字字符串的整数，无法解析时为 0
+type Int int
+
+func (i *Int) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseFloat(unquote(b), 64)
+	if err != nil {
+		v = 0
+	}
+	*i = Int(v)
+	return nil
+}
+
+// String 兼容字符串与数字（如时间戳），数字保留原始文本，null 为空字符串
+type String string
+
+func (s *String) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '"' {
+		var v string
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		*s = String(v)
+		return nil
+	}
+	if string(b) == "null" {
+		*s = ""
+		return nil
+	}
+	*s = String(b)
+	return nil
+}
+
+// Bool 兼容布尔值与布尔字符串，无法解析时为 false
+type Bool bool
+
+func (v *Bool) UnmarshalJSON(b []byte) error {
+	parsed, err := strconv.ParseBool(unquote(b))
+	*v = Bool(err == nil && parsed)
+	return nil
+}
+
+// unquote 去掉 JSON 字符串两侧的引号
+func unquote(b []byte) string {
+	return string(bytes.Trim(bytes.TrimSpace(b), `"`))
+}
@@ -0,0 +1,107 @@
+package valuescan
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLenientScalarsDecode(t *testing.T) {
+	tests := []struct {
+		input      string
+		wantFloat  Float
+		wantInt    Int
+		wantString String
+		wantBool   Bool
+	}{
+		{input: `12.5`, wantFloat: 12.5, wantInt: 12, wantString: "12.5"},
+		{input: `"12.5"`, wantFloat: 12.5, wantInt: 12, wantString: "12.5"},
+		{input: `-3`, wantFloat: -3, wantInt: -3, wantString: "-3"},
+		{input: `1700000000000`, wantFloat: 1700000000000, wantInt: 1700000000000, wantString: "1700000000000"},
+		{input: `""`},
+		{input: `null`},
+		{input: `"abc"`, wantString: "abc"},
+		{input: `true`, wantString: "true", wantBool: true},
+		{input: `"true"`, wantString: "true", wantBool: true},
+		{input: `"1"`, wantFloat: 1, wantInt: 1, wantString: "1", wantBool: true},
+		{input: `"false"`, wantString: "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var v struct {
+				F Float  `json:"f"`
+				I Int    `json:"i"`
+				S String `json:"s"`
+				B Bool   `json:"b"`
+			}
+			doc := `{"f":` + tt.input + `,"i":` + tt.input + `,"s":` + tt.input + `,"b":` + tt.input + `}`
+			if err := json.Unmarshal([]byte(doc), &v); err != nil {
+				t.Fatalf("unmarshal %s: %v", doc, err)
+			}
+			if v.F != tt.wantFloat || v.I != tt.wantInt || v.S != tt.wantString || v.B != tt.wantBool {
+				t.Fatalf("got = %+v, want F=%v I=%v S=%q B=%v", v, tt.wantFloat, tt.wantInt, tt.wantString, tt.wantBool)
+			}
+		})
+	}
+}
+
+func TestResponseDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantMeta Meta
+		wantData TradeInflow
+		wantErr  bool
+	}{
+		{
+			name:     "data object",
+			body:     `{"code":200,"msg":"success","reqId":"r1","userRole":"vip","data":{"symbol":"BTC","coinTradeInflowDtoList":[{"timeParticleEnum":"1","time":1700000000000,"stop":"true","stopTradeInflow":"1.5"}]}}`,
+			wantMeta: Meta{Code: 200, Msg: "success", ReqID: "r1", UserRole: "vip"},
+			wantData: TradeInflow{Symbol: "BTC", List: []TradeInflowItem{{TimeParticleEnum: 1, Time: "1700000000000", Stop: true, StopTradeInflow: 1.5}}},
+		},
+		{
+			name:     "empty string data",
+			body:     `{"code":4000,"msg":"token invalid","reqId":"r2","data":""}`,
+			wantMeta: Meta{Code: 4000, Msg: "token invalid", ReqID: "r2"},
+		},
+		{
+			name:     "null data",
+			body:     `{"code":200,"msg":"success","data":null}`,
+			wantMeta: Meta{Code: 200, Msg: "success"},
+		},
+		{
+			name:     "missing data",
+			body:     `{"code":200,"msg":"success"}`,
+			wantMeta: Meta{Code: 200, Msg: "success"},
+		},
+		{name: "data of wrong type", body: `{"code":200,"data":[1,2]}`, wantErr: true},
+		{name: "not json", body: `<html>`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp Response[TradeInflow]
+			err := json.Unmarshal([]byte(tt.body), &resp)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("unmarshal = %+v, want error", resp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if resp.Meta != tt.wantMeta {
+				t.Fatalf("meta = %+v, want %+v", resp.Meta, tt.wantMeta)
+			}
+			if resp.Data.Symbol != tt.wantData.Symbol || len(resp.Data.List) != len(tt.wantData.List) {
+				t.Fatalf("data = %+v, want %+v", resp.Data, tt.wantData)
+			}
+			for i := range tt.wantData.List {
+				if resp.Data.List[i] != tt.wantData.List[i] {
+					t.Fatalf("item %d = %+v, want %+v", i, resp.Data.List[i], tt.wantData.List[i])
+				}
+			}
+		})
+	}
+}